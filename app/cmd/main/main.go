@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	_ "finance-manager-api-service/docs"
+	"finance-manager-api-service/internal/apperror"
 	"finance-manager-api-service/internal/client/operation_service/category"
 	"finance-manager-api-service/internal/client/operation_service/operation"
 	"finance-manager-api-service/internal/client/stats_service"
@@ -15,18 +16,31 @@ import (
 	"finance-manager-api-service/internal/handler/operations"
 	"finance-manager-api-service/internal/handler/stats"
 	"finance-manager-api-service/internal/handler/users"
+	"finance-manager-api-service/internal/ownership"
+	"finance-manager-api-service/pkg/breaker"
+	"finance-manager-api-service/pkg/cache"
+	"finance-manager-api-service/pkg/cache/bolt"
 	"finance-manager-api-service/pkg/cache/freecache"
+	"finance-manager-api-service/pkg/cache/redis"
+	"finance-manager-api-service/pkg/exchange"
+	"finance-manager-api-service/pkg/health"
 	"finance-manager-api-service/pkg/jwt"
 	"finance-manager-api-service/pkg/logging"
 	"finance-manager-api-service/pkg/metric"
+	"finance-manager-api-service/pkg/money"
+	"finance-manager-api-service/pkg/ratelimit"
+	"finance-manager-api-service/pkg/rest"
+	"finance-manager-api-service/pkg/retry"
 	"finance-manager-api-service/pkg/shutdown"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
 	"github.com/rs/cors"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"io"
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"syscall"
 	"time"
 )
@@ -50,14 +64,25 @@ func main() {
 	logger.Info("config initializing")
 	cfg := config.GetConfig()
 
+	apperror.UseProblemDetails(cfg.ErrorResponse.Format == "problem", cfg.ErrorResponse.ProblemTypeBaseURI)
+	money.EncodeAsString(cfg.Currency.AmountFormat == "string")
+
 	logger.Info("router initializing")
 	router := httprouter.New()
+	metrics := metric.NewMetrics()
 
 	logger.Info("cache initializing")
-	refreshTokenCache := freecache.NewCacheRepo(104857600) //100MB
+	refreshTokenCache, err := newRefreshTokenCache(cfg, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	metrics.RegisterCache("refresh_token", refreshTokenCache)
 
 	logger.Info("jwt helper initializing")
-	jwtHelper := jwt.NewHelper(refreshTokenCache, logger)
+	jwtKeys := jwt.GetKeySet()
+	revocationList := jwt.NewRevocationList(refreshTokenCache, logger)
+	jwt.SetRevocationList(revocationList)
+	jwtHelper := jwt.NewHelper(refreshTokenCache, revocationList, logger)
 
 	logger.Info("create and register handlers")
 
@@ -65,43 +90,152 @@ func main() {
 	router.Handler(http.MethodGet, "/swagger", http.RedirectHandler("/swagger/index.html", http.StatusMovedPermanently))
 	router.Handler(http.MethodGet, "/swagger/*any", httpSwagger.WrapHandler)
 
-	metricHandler := metric.NewHandler(logger)
-	metricHandler.Register(router)
+	userBreaker := newBreaker("user-service", cfg.UserService.CircuitBreaker, logger, metrics)
+	operationBreaker := newBreaker("operation-service", cfg.OperationService.CircuitBreaker, logger, metrics)
+	statsBreaker := newBreaker("stats-service", cfg.StatsService.CircuitBreaker, logger, metrics)
+	metrics.RegisterBreakers(userBreaker, operationBreaker, statsBreaker)
+
+	jwksHandler := jwt.NewJWKSHandler(logger, jwtKeys)
+	jwksHandler.Register(router)
+
+	clientCfg := cfg.HTTPClient
+	httpClient := &http.Client{
+		Timeout: clientCfg.Timeout,
+		Transport: rest.NewTransport(rest.TransportConfig{
+			MaxIdleConns:        clientCfg.MaxIdleConns,
+			MaxIdleConnsPerHost: clientCfg.MaxIdleConnsPerHost,
+			MaxConnsPerHost:     clientCfg.MaxConnsPerHost,
+			IdleConnTimeout:     clientCfg.IdleConnTimeout,
+			KeepAlive:           clientCfg.KeepAlive,
+			DialTimeout:         clientCfg.DialTimeout,
+			TLSHandshakeTimeout: clientCfg.TLSHandshakeTimeout,
+		}),
+	}
 
 	var userService user_service.UserService
 	if cfg.UserService.ConnectWithGRPC == true {
-		var err error
 		logger.Info("connect to user service through grpc")
-		userService, err = user_service_grpc.NewClient(cfg.UserService.GrpcUrl, logger)
+		retryableCodes, err := retry.ParseCodes(cfg.UserService.Retry.RetryableCodes)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		userService, err = user_service_grpc.NewClient(cfg.UserService.GrpcUrl, logger,
+			retryPolicy(cfg.UserService.Retry), retryableCodes, userBreaker, metrics.Upstream("user-service"))
 		if err != nil {
 			logger.Fatal(err.Error())
 		}
 	} else {
 		logger.Info("connect to user service through http")
-		userService = user_service_http.NewService(cfg.UserService.HttpUrl, "/users", logger)
+		userService = user_service_http.NewService(cfg.UserService.HttpUrl, "/users", logger, httpClient,
+			restRetryPolicy(cfg.UserService.Retry), userBreaker, metrics.Upstream("user-service"))
 	}
-	authHandler := auth.NewAuthHandler(logger, userService, jwtHelper)
+	authLimits := cfg.RateLimit.Auth
+	lockoutCfg := cfg.RateLimit.LoginLockout
+	authHandler := auth.NewAuthHandler(logger, userService, jwtHelper,
+		ratelimit.NewLimiter(authLimits.IP.Requests, authLimits.IP.Period, authLimits.IP.Burst),
+		ratelimit.NewLimiter(authLimits.Email.Requests, authLimits.Email.Period, authLimits.Email.Burst),
+		ratelimit.NewLockout(refreshTokenCache, logger, lockoutCfg.MaxFailures, lockoutCfg.BaseDuration,
+			lockoutCfg.MaxDuration, lockoutCfg.FailureWindow))
 	authHandler.Register(router)
-	userHandler := users.NewUserHandler(logger, userService)
+	userHandler := users.NewUserHandler(logger, userService, jwtHelper)
 	userHandler.Register(router)
 
-	categoryService := category.NewService(cfg.OperationService.URL, "/categories", logger)
-	categoryHandler := categories.NewCategoryHandler(logger, categoryService)
+	operationRetry := restRetryPolicy(cfg.OperationService.Retry)
+	operationMetrics := metrics.Upstream("operation-service")
+	categoryService := category.NewService(cfg.OperationService.URL, "/categories", logger, httpClient,
+		operationRetry, operationBreaker, operationMetrics)
+	operationService := operation.NewService(cfg.OperationService.URL, "/operations", logger, httpClient,
+		operationRetry, operationBreaker, operationMetrics)
+	ownershipChecker := ownership.NewChecker(logger, categoryService, operationService)
+
+	categoryHandler := categories.NewCategoryHandler(logger, categoryService, ownershipChecker)
 	categoryHandler.Register(router)
 
-	operationService := operation.NewService(cfg.OperationService.URL, "/operations", logger)
-	operationHandler := operations.NewOperationHandler(logger, operationService)
+	batchCfg := cfg.OperationService.Batch
+	importCfg := cfg.OperationService.Import
+	operationHandler := operations.NewOperationHandler(logger, operationService, categoryService, userService,
+		ownershipChecker, operations.BatchLimits{MaxItems: batchCfg.MaxItems, Concurrency: batchCfg.Concurrency},
+		operations.ImportLimits{MaxFileSize: importCfg.MaxFileSize, MaxTransactions: importCfg.MaxTransactions},
+		cfg.Currency.Default)
 	operationHandler.Register(router)
 
-	statsService := stats_service.NewService(cfg.StatsService.URL, "/stats", logger)
-	statsHandler := stats.NewHandler(logger, statsService)
+	logger.Info("load exchange rates")
+	exchangeRates, err := exchange.NewFileProvider(cfg.Currency.RatesFile, cfg.Currency.Default)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	statsService := stats_service.NewService(cfg.StatsService.URL, "/stats", logger, httpClient,
+		restRetryPolicy(cfg.StatsService.Retry), statsBreaker, metrics.Upstream("stats-service"))
+	statsHandler := stats.NewHandler(logger, statsService, userService, categoryService, exchangeRates, cfg.Currency.Default)
 	statsHandler.Register(router)
 
+	readiness := health.NewProbe(cfg.Health.Timeout, cfg.Health.CacheTTL,
+		health.Dependency{Name: "user-service", Check: userService.CheckHealth},
+		health.Dependency{Name: "operation-service", Check: operationService.CheckHealth},
+		health.Dependency{Name: "stats-service", Check: statsService.CheckHealth},
+	)
+	metricHandler := metric.NewHandler(logger, readiness, metrics, userBreaker, operationBreaker, statsBreaker)
+	metricHandler.Register(router)
+
 	logger.Info("start application")
-	start(router, logger, cfg)
+	start(metrics.Middleware(router), logger, cfg, refreshTokenCache)
+}
+
+func retryPolicy(cfg config.RetryPolicy) retry.Policy {
+	return retry.Policy{
+		MaxAttempts: cfg.MaxAttempts,
+		BaseDelay:   cfg.BaseDelay,
+		MaxDelay:    cfg.MaxDelay,
+	}
+}
+
+func restRetryPolicy(cfg config.RetryPolicy) rest.RetryPolicy {
+	return rest.RetryPolicy{
+		Policy:   retryPolicy(cfg),
+		Statuses: cfg.RetryableStatuses,
+	}
+}
+
+// newBreaker returns nil when the breaker is disabled, nil breaker lets all calls through
+func newBreaker(name string, cfg config.CircuitBreaker, logger *logging.Logger, metrics *metric.Metrics) *breaker.Breaker {
+	if cfg.FailureThreshold < 0 {
+		return nil
+	}
+	return breaker.New(name, breaker.Config{
+		FailureThreshold: cfg.FailureThreshold,
+		OpenTimeout:      cfg.OpenTimeout,
+		HalfOpenRequests: cfg.HalfOpenRequests,
+		OnStateChange: func(name string, from, to breaker.State) {
+			logger.Warnf("circuit breaker of %s changed state from %s to %s", name, from, to)
+			metrics.BreakerStateChanged(name, from, to)
+		},
+		OnReject: metrics.BreakerRejected,
+	})
+}
+
+func newRefreshTokenCache(cfg *config.Config, logger *logging.Logger) (cache.Repository, error) {
+	storeCfg := cfg.RefreshTokenStore
+	switch storeCfg.Type {
+	case "memory":
+		logger.Info("store refresh tokens in memory")
+		return freecache.NewCacheRepo(storeCfg.Memory.Size), nil
+	case "bolt":
+		logger.Infof("store refresh tokens in bolt db: %s", storeCfg.Bolt.Path)
+		if err := os.MkdirAll(filepath.Dir(storeCfg.Bolt.Path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create bolt db dir: %w", err)
+		}
+		return bolt.NewCacheRepo(storeCfg.Bolt.Path)
+	case "redis":
+		logger.Infof("store refresh tokens in redis: %s", storeCfg.Redis.Addr)
+		return redis.NewCacheRepo(storeCfg.Redis.Addr, storeCfg.Redis.Password, storeCfg.Redis.DB,
+			storeCfg.Redis.KeyPrefix)
+	default:
+		return nil, fmt.Errorf("unknown refresh token store type: %s", storeCfg.Type)
+	}
 }
 
-func start(router *httprouter.Router, logger *logging.Logger, cfg *config.Config) {
+func start(router http.Handler, logger *logging.Logger, cfg *config.Config, closeItems ...io.Closer) {
 	logger.Infof("bind application to host: %s and port: %d", cfg.HTTP.IP, cfg.HTTP.Port)
 
 	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", cfg.HTTP.IP, cfg.HTTP.Port))
@@ -127,7 +261,7 @@ func start(router *httprouter.Router, logger *logging.Logger, cfg *config.Config
 	}
 
 	go shutdown.Graceful([]os.Signal{syscall.SIGABRT, syscall.SIGQUIT, syscall.SIGHUP, os.Interrupt, syscall.SIGTERM},
-		server)
+		append([]io.Closer{server}, closeItems...)...)
 
 	logger.Info("application initialized and started")
 
@@ -2,11 +2,12 @@ package apperror
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+	"net/http"
 )
 
 var (
-	ErrNotFound = NewAppError("API-000404", "not found", "not found")
+	ErrNotFound = newAppError(http.StatusNotFound, "API-000404", "not found", "not found")
 )
 
 type ErrorFields map[string]string
@@ -14,6 +15,7 @@ type ErrorParams map[string]string
 
 type AppError struct {
 	Err              error       `json:"-"`
+	Status           int         `json:"-"`
 	Code             string      `json:"code,omitempty"`
 	Message          string      `json:"message,omitempty"`
 	DeveloperMessage string      `json:"developer_message,omitempty"`
@@ -21,9 +23,15 @@ type AppError struct {
 	Params           ErrorParams `json:"params,omitempty"`
 }
 
+// NewAppError creates error which is rendered with 400 Bad Request status
 func NewAppError(code, message, developerMessage string) *AppError {
+	return newAppError(http.StatusBadRequest, code, message, developerMessage)
+}
+
+func newAppError(status int, code, message, developerMessage string) *AppError {
 	return &AppError{
-		Err:              fmt.Errorf(message),
+		Err:              errors.New(message),
+		Status:           status,
 		Code:             code,
 		Message:          message,
 		DeveloperMessage: developerMessage,
@@ -39,7 +47,11 @@ func (e *AppError) Unwrap() error {
 }
 
 func (e *AppError) Marshal() []byte {
-	bytes, err := json.Marshal(e)
+	return marshal(e)
+}
+
+func marshal(v any) []byte {
+	bytes, err := json.Marshal(v)
 	if err != nil {
 		return nil
 	}
@@ -54,16 +66,56 @@ func (e *AppError) WithParams(params ErrorParams) {
 	e.Params = params
 }
 
+// HTTPStatus returns status the error is rendered with, errors without status are client errors
+func (e *AppError) HTTPStatus() int {
+	if e.Status == 0 {
+		return http.StatusBadRequest
+	}
+	return e.Status
+}
+
 func UnauthorizedError(message string) *AppError {
-	return NewAppError("API-000401", message, "user unauthorized")
+	return newAppError(http.StatusUnauthorized, "API-000401", message, "user unauthorized")
 }
 
 func BadRequestError(message string) *AppError {
-	return NewAppError("API-000400", message, "something wrong with user data")
+	return newAppError(http.StatusBadRequest, "API-000400", message, "something wrong with user data")
+}
+
+func ForbiddenError(message string) *AppError {
+	return newAppError(http.StatusForbidden, "API-000403", message, "access denied")
+}
+
+func NotAcceptableError(message string) *AppError {
+	return newAppError(http.StatusNotAcceptable, "API-000406", message, "requested representation is not supported")
+}
+
+func ConflictError(message string) *AppError {
+	return newAppError(http.StatusConflict, "API-000409", message, "resource state conflict")
+}
+
+func UnprocessableEntityError(message string) *AppError {
+	return newAppError(http.StatusUnprocessableEntity, "API-000422", message, "validation failed")
+}
+
+func TooManyRequestsError(message string) *AppError {
+	return newAppError(http.StatusTooManyRequests, "API-000429", message, "rate limit exceeded")
+}
+
+func BadGatewayError(developerMessage string) *AppError {
+	return newAppError(http.StatusBadGateway, "API-000502", "upstream service error", developerMessage)
+}
+
+func ServiceUnavailableError(developerMessage string) *AppError {
+	return newAppError(http.StatusServiceUnavailable, "API-000503", "service unavailable", developerMessage)
+}
+
+func GatewayTimeoutError(developerMessage string) *AppError {
+	return newAppError(http.StatusGatewayTimeout, "API-000504", "upstream service timeout", developerMessage)
 }
 
 func systemError(developerMessage string) *AppError {
-	return NewAppError("API-000418", "internal system error", developerMessage)
+	return newAppError(http.StatusTeapot, "API-000418", "internal system error", developerMessage)
 }
 
 func APIError(code, message, developerMessage string) *AppError {
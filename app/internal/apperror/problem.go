@@ -0,0 +1,106 @@
+package apperror
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is RFC 7807 representation of AppError
+type Problem struct {
+	Type             string         `json:"type"`
+	Title            string         `json:"title"`
+	Status           int            `json:"status"`
+	Detail           string         `json:"detail,omitempty"`
+	Instance         string         `json:"instance,omitempty"`
+	Code             string         `json:"code,omitempty"`
+	DeveloperMessage string         `json:"developer_message,omitempty"`
+	Errors           []ProblemField `json:"errors,omitempty"`
+	Params           ErrorParams    `json:"params,omitempty"`
+}
+
+type ProblemField struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+var (
+	problemByDefault   bool
+	problemTypeBaseURI = "/errors/"
+)
+
+// UseProblemDetails configures rendering of errors. Problem details are always rendered when byDefault
+// is set, otherwise only to clients that accept application/problem+json. Problem type is typeBaseURI
+// followed by the error code.
+func UseProblemDetails(byDefault bool, typeBaseURI string) {
+	problemByDefault = byDefault
+	if typeBaseURI != "" {
+		problemTypeBaseURI = typeBaseURI
+	}
+}
+
+// Problem converts error to problem details, instance is the request path
+func (e *AppError) Problem(instance string) Problem {
+	status := e.HTTPStatus()
+	problem := Problem{
+		Type:             problemType(e.Code),
+		Title:            http.StatusText(status),
+		Status:           status,
+		Detail:           e.Message,
+		Instance:         instance,
+		Code:             e.Code,
+		DeveloperMessage: e.DeveloperMessage,
+		Params:           e.Params,
+	}
+
+	for field, detail := range e.Fields {
+		problem.Errors = append(problem.Errors, ProblemField{Field: field, Detail: detail})
+	}
+	sort.Slice(problem.Errors, func(i, j int) bool {
+		return problem.Errors[i].Field < problem.Errors[j].Field
+	})
+	return problem
+}
+
+func problemType(code string) string {
+	if code == "" {
+		return "about:blank"
+	}
+	return problemTypeBaseURI + url.PathEscape(strings.ToLower(code))
+}
+
+// Render writes error to response as problem details or as AppError JSON depending on
+// configuration and Accept header of the request
+func Render(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := From(err)
+
+	var body []byte
+	if wantsProblem(r) {
+		w.Header().Set("Content-Type", problemContentType)
+		body = marshal(appErr.Problem(r.URL.Path))
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		body = appErr.Marshal()
+	}
+
+	w.WriteHeader(appErr.HTTPStatus())
+	_, _ = w.Write(body)
+}
+
+func wantsProblem(r *http.Request) bool {
+	if problemByDefault {
+		return true
+	}
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, _, _ := strings.Cut(mediaRange, ";")
+			if strings.EqualFold(strings.TrimSpace(mediaType), problemContentType) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"finance-manager-api-service/pkg/breaker"
+	"finance-manager-api-service/pkg/rest"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// FromAPIResponse converts error response of downstream service. Client errors keep their status,
+// server errors of downstream service are reported as gateway errors.
+func FromAPIResponse(response *rest.APIResponse) *AppError {
+	appErr := newAppError(upstreamStatus(response.StatusCode()), response.Error.Code, response.Error.Message,
+		response.Error.DeveloperMessage)
+	if appErr.Message == "" {
+		appErr.Err = errors.New(http.StatusText(response.StatusCode()))
+		appErr.Message = appErr.Err.Error()
+	}
+	if len(response.Error.Fields) > 0 {
+		appErr.WithFields(ErrorFields(response.Error.Fields))
+	}
+	if len(response.Error.Params) > 0 {
+		appErr.WithParams(ErrorParams(response.Error.Params))
+	}
+	return appErr
+}
+
+func upstreamStatus(status int) int {
+	switch {
+	case status == http.StatusServiceUnavailable, status == http.StatusGatewayTimeout:
+		return status
+	case status >= http.StatusInternalServerError:
+		return http.StatusBadGateway
+	case status >= http.StatusBadRequest:
+		return status
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// From returns AppError wrapped by err or classifies err as gateway or internal error
+func From(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return fromError(err)
+}
+
+// fromError classifies errors that are not AppError: failures to reach downstream services
+// are gateway errors, anything else is internal error
+func fromError(err error) *AppError {
+	if errors.Is(err, breaker.ErrOpen) {
+		return ServiceUnavailableError(err.Error())
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return GatewayTimeoutError(err.Error())
+	}
+
+	var urlErr *url.Error
+	var opErr *net.OpError
+	if errors.As(err, &urlErr) || errors.As(err, &opErr) {
+		return BadGatewayError(err.Error())
+	}
+
+	return systemError(err.Error())
+}
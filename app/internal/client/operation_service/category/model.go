@@ -8,12 +8,12 @@ type Category struct {
 }
 
 type CreateCategoryDTO struct {
-	UserUUID string `json:"user_uuid"`
-	Name     string `json:"name"`
-	Type     string `json:"type"`
+	UserUUID string `json:"user_uuid" validate:"required,uuid"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Type     string `json:"type" validate:"required,oneof=income expense"`
 }
 
 type UpdateCategoryDTO struct {
-	UUID string `json:"uuid"`
-	Name string `json:"name"`
+	UUID string `json:"uuid" validate:"required,uuid"`
+	Name string `json:"name" validate:"required,max=100"`
 }
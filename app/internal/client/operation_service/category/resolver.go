@@ -0,0 +1,65 @@
+package category
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Info is category embedded into operations when they are requested with expand=category
+type Info struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Resolver looks up categories of one user. All categories of the user are loaded with a single
+// request on first use and kept till the resolver is dropped, so it is meant to live as long as
+// one API request. Not safe for concurrent use.
+type Resolver struct {
+	service    Service
+	userUUID   string
+	categories map[string]Category
+}
+
+func NewResolver(service Service, userUUID string) *Resolver {
+	return &Resolver{
+		service:  service,
+		userUUID: userUUID,
+	}
+}
+
+// Categories returns categories of the user keyed by UUID
+func (r *Resolver) Categories(ctx context.Context) (map[string]Category, error) {
+	if r.categories != nil {
+		return r.categories, nil
+	}
+
+	categoryBytes, err := r.service.GetByUserUUID(ctx, r.userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	var categories []Category
+	if err = json.Unmarshal(categoryBytes, &categories); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal categories: %w", err)
+	}
+
+	r.categories = make(map[string]Category, len(categories))
+	for _, ctg := range categories {
+		r.categories[ctg.UUID] = ctg
+	}
+	return r.categories, nil
+}
+
+// Info returns name and type of the category, nil when the user has no such category
+func (r *Resolver) Info(ctx context.Context, categoryUUID string) (*Info, error) {
+	categories, err := r.Categories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctg, ok := categories[categoryUUID]
+	if !ok {
+		return nil, nil
+	}
+	return &Info{Name: ctg.Name, Type: ctg.Type}, nil
+}
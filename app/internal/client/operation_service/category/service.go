@@ -5,7 +5,9 @@ import (
 	"context"
 	"encoding/json"
 	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/pkg/breaker"
 	"finance-manager-api-service/pkg/logging"
+	"finance-manager-api-service/pkg/metric"
 	"finance-manager-api-service/pkg/rest"
 	"fmt"
 	"net/http"
@@ -28,15 +30,17 @@ type client struct {
 	Resource string
 }
 
-func NewService(baseURL, resource string, logger *logging.Logger) Service {
+func NewService(baseURL, resource string, logger *logging.Logger, httpClient *http.Client,
+	retryPolicy rest.RetryPolicy, circuitBreaker *breaker.Breaker, upstreamMetrics *metric.Upstream) Service {
 	return &client{
 		Resource: resource,
 		base: rest.BaseClient{
-			BaseURL: baseURL,
-			HTTPClient: &http.Client{
-				Timeout: 10 * time.Second,
-			},
-			Logger: logger,
+			BaseURL:    baseURL,
+			HTTPClient: httpClient,
+			Logger:     logger,
+			Retry:      retryPolicy,
+			Breaker:    circuitBreaker,
+			Metrics:    upstreamMetrics,
 		},
 	}
 }
@@ -67,13 +71,14 @@ func (c *client) Create(ctx context.Context, dto CreateCategoryDTO) (string, err
 	reqCtx, cancel := context.WithTimeout(ctx, requestWaitTime)
 	defer cancel()
 	req = req.WithContext(reqCtx)
+	rest.SetIdempotencyKey(req)
 	response, err := c.base.SendRequest(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
 
 	if !response.IsOk {
-		return "", apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return "", apperror.FromAPIResponse(response)
 	}
 	c.base.Logger.Debug("parse location header")
 	categoryURL, err := response.Location()
@@ -115,7 +120,7 @@ func (c *client) GetByUUID(ctx context.Context, uuid string) ([]byte, error) {
 	}
 
 	if !response.IsOk {
-		return category, apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return category, apperror.FromAPIResponse(response)
 	}
 	c.base.Logger.Debug("read response body")
 	category, err = response.ReadBody()
@@ -152,7 +157,7 @@ func (c *client) GetByUserUUID(ctx context.Context, userUUID string) ([]byte, er
 	}
 
 	if !response.IsOk {
-		return categories, apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return categories, apperror.FromAPIResponse(response)
 	}
 	c.base.Logger.Debug("read response body")
 	categories, err = response.ReadBody()
@@ -194,7 +199,7 @@ func (c *client) Update(ctx context.Context, dto UpdateCategoryDTO) error {
 	}
 
 	if !response.IsOk {
-		return apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return apperror.FromAPIResponse(response)
 	}
 	return nil
 }
@@ -225,7 +230,7 @@ func (c *client) Delete(ctx context.Context, uuid string) error {
 	}
 
 	if !response.IsOk {
-		return apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return apperror.FromAPIResponse(response)
 	}
 	return nil
 }
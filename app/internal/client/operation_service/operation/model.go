@@ -1,23 +1,72 @@
 package operation
 
-import "time"
+import (
+	"encoding/json"
+	"finance-manager-api-service/internal/client/operation_service/category"
+	"finance-manager-api-service/pkg/money"
+	"time"
+)
 
+// Operation with empty currency is in base currency of the user
 type Operation struct {
-	UUID         string    `json:"uuid"`
-	CategoryUUID string    `json:"category_uuid"`
-	MoneySum     float64   `json:"money_sum"`
-	Description  string    `json:"description"`
-	DateTime     time.Time `json:"date_time"`
+	UUID         string       `json:"uuid"`
+	CategoryUUID string       `json:"category_uuid"`
+	MoneySum     money.Amount `json:"money_sum" swaggertype:"number"`
+	Currency     string       `json:"currency,omitempty"`
+	Description  string       `json:"description"`
+	DateTime     time.Time    `json:"date_time"`
+	// set only when requested with expand=category
+	Category *category.Info `json:"category,omitempty"`
 }
 
 type CreateOperationDTO struct {
-	CategoryUUID string  `json:"category_uuid"`
-	MoneySum     float64 `json:"money_sum"`
-	Description  string  `json:"description"`
+	CategoryUUID string       `json:"category_uuid" validate:"required,uuid"`
+	MoneySum     money.Amount `json:"money_sum" validate:"required,gt=0" swaggertype:"number"`
+	Currency     string       `json:"currency,omitempty" validate:"omitempty,currency"`
+	Description  string       `json:"description" validate:"max=1000"`
+	// downstream service stamps current time when date is omitted
+	DateTime *time.Time `json:"date_time,omitempty" validate:"omitempty,maxfuture=24h"`
 }
 
+// UpdateOperationDTO leaves fields with zero values unchanged
 type UpdateOperationDTO struct {
-	CategoryUUID string  `json:"category_uuid"`
-	MoneySum     float64 `json:"money_sum"`
-	Description  string  `json:"description"`
+	CategoryUUID string       `json:"category_uuid" validate:"omitempty,uuid"`
+	MoneySum     money.Amount `json:"money_sum" validate:"omitempty,gt=0" swaggertype:"number"`
+	Currency     string       `json:"currency,omitempty" validate:"omitempty,currency"`
+	Description  string       `json:"description" validate:"max=1000"`
+	DateTime     *time.Time   `json:"date_time,omitempty" validate:"omitempty,maxfuture=24h"`
+}
+
+type OperationsPage struct {
+	Operations []Operation `json:"operations"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+	HasMore    bool        `json:"has_more"`
+}
+
+// MarshalJSON keeps money sum a number in requests to the operation-service, whatever encoding
+// of amounts is chosen for clients of the API
+func (dto CreateOperationDTO) MarshalJSON() ([]byte, error) {
+	type fields CreateOperationDTO
+	return json.Marshal(struct {
+		fields
+		MoneySum json.Number `json:"money_sum"`
+	}{fields(dto), dto.MoneySum.Number()})
+}
+
+func (dto UpdateOperationDTO) MarshalJSON() ([]byte, error) {
+	type fields UpdateOperationDTO
+	return json.Marshal(struct {
+		fields
+		MoneySum json.Number `json:"money_sum"`
+	}{fields(dto), dto.MoneySum.Number()})
+}
+
+// toUTC normalizes time of operation, so downstream services and clients always get time in UTC
+func toUTC(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
 }
@@ -5,8 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/pkg/breaker"
 	"finance-manager-api-service/pkg/logging"
+	"finance-manager-api-service/pkg/metric"
 	"finance-manager-api-service/pkg/rest"
+	"finance-manager-api-service/pkg/utils"
 	"fmt"
 	"net/http"
 	"strings"
@@ -18,8 +21,11 @@ const requestWaitTime = 5 * time.Second
 type Service interface {
 	Create(ctx context.Context, dto CreateOperationDTO) (string, error)
 	GetByUUID(ctx context.Context, uuid string) ([]byte, error)
+	GetByFilters(ctx context.Context, filters []rest.FilterOptions) ([]Operation, error)
 	Update(ctx context.Context, uuid string, dto UpdateOperationDTO) error
 	Delete(ctx context.Context, uuid string) error
+	// CheckHealth returns error when the operation service can not serve requests
+	CheckHealth(ctx context.Context) error
 }
 
 type client struct {
@@ -27,15 +33,17 @@ type client struct {
 	Resource string
 }
 
-func NewService(baseURL, resource string, logger *logging.Logger) Service {
+func NewService(baseURL, resource string, logger *logging.Logger, httpClient *http.Client,
+	retryPolicy rest.RetryPolicy, circuitBreaker *breaker.Breaker, upstreamMetrics *metric.Upstream) Service {
 	return &client{
 		Resource: resource,
 		base: rest.BaseClient{
-			BaseURL: baseURL,
-			HTTPClient: &http.Client{
-				Timeout: 10 * time.Second,
-			},
-			Logger: logger,
+			BaseURL:    baseURL,
+			HTTPClient: httpClient,
+			Logger:     logger,
+			Retry:      retryPolicy,
+			Breaker:    circuitBreaker,
+			Metrics:    upstreamMetrics,
 		},
 	}
 }
@@ -51,6 +59,7 @@ func (c *client) Create(ctx context.Context, dto CreateOperationDTO) (string, er
 	c.base.Logger.Tracef("url: %s", url)
 
 	c.base.Logger.Debug("marshal dto to bytes")
+	dto.DateTime = toUTC(dto.DateTime)
 	dataBytes, err := json.Marshal(dto)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal dto: %w", err)
@@ -66,13 +75,14 @@ func (c *client) Create(ctx context.Context, dto CreateOperationDTO) (string, er
 	reqCtx, cancel := context.WithTimeout(ctx, requestWaitTime)
 	defer cancel()
 	req = req.WithContext(reqCtx)
+	rest.SetIdempotencyKey(req)
 	response, err := c.base.SendRequest(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
 
 	if !response.IsOk {
-		return "", apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return "", apperror.FromAPIResponse(response)
 	}
 	c.base.Logger.Debug("parse location header")
 	operationURL, err := response.Location()
@@ -114,7 +124,7 @@ func (c *client) GetByUUID(ctx context.Context, uuid string) ([]byte, error) {
 	}
 
 	if !response.IsOk {
-		return operation, apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return operation, apperror.FromAPIResponse(response)
 	}
 	c.base.Logger.Debug("read response body")
 	operation, err = response.ReadBody()
@@ -124,6 +134,45 @@ func (c *client) GetByUUID(ctx context.Context, uuid string) ([]byte, error) {
 	return operation, nil
 }
 
+func (c *client) GetByFilters(ctx context.Context, filters []rest.FilterOptions) ([]Operation, error) {
+	c.base.Logger.Info("Get operations by filters")
+	var operations []Operation
+
+	c.base.Logger.Debug("build url")
+	url, err := c.base.BuildURL(c.Resource, filters)
+	if err != nil {
+		return operations, fmt.Errorf("failed to build url: %w", err)
+	}
+	c.base.Logger.Tracef("url: %s", url)
+
+	c.base.Logger.Debug("create request")
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return operations, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.base.Logger.Debug("send request")
+	reqCtx, cancel := context.WithTimeout(ctx, requestWaitTime)
+	defer cancel()
+	req = req.WithContext(reqCtx)
+	response, err := c.base.SendRequest(req)
+	if err != nil {
+		return operations, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if !response.IsOk {
+		return operations, apperror.FromAPIResponse(response)
+	}
+	defer utils.CloseBody(c.base.Logger, response.Body())
+	if err = json.NewDecoder(response.Body()).Decode(&operations); err != nil {
+		return operations, fmt.Errorf("failed to decode response: %w", err)
+	}
+	for i := range operations {
+		operations[i].DateTime = operations[i].DateTime.UTC()
+	}
+	return operations, nil
+}
+
 func (c *client) Update(ctx context.Context, uuid string, dto UpdateOperationDTO) error {
 	c.base.Logger.Info("Update operation")
 
@@ -135,6 +184,7 @@ func (c *client) Update(ctx context.Context, uuid string, dto UpdateOperationDTO
 	c.base.Logger.Tracef("url: %s", url)
 
 	c.base.Logger.Debug("marshal dto to bytes")
+	dto.DateTime = toUTC(dto.DateTime)
 	dataBytes, err := json.Marshal(dto)
 	if err != nil {
 		return fmt.Errorf("failed to marshal dto: %w", err)
@@ -156,7 +206,7 @@ func (c *client) Update(ctx context.Context, uuid string, dto UpdateOperationDTO
 	}
 
 	if !response.IsOk {
-		return apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return apperror.FromAPIResponse(response)
 	}
 	return nil
 }
@@ -187,7 +237,11 @@ func (c *client) Delete(ctx context.Context, uuid string) error {
 	}
 
 	if !response.IsOk {
-		return apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return apperror.FromAPIResponse(response)
 	}
 	return nil
 }
+
+func (c *client) CheckHealth(ctx context.Context) error {
+	return c.base.Ping(ctx, rest.HeartbeatResource)
+}
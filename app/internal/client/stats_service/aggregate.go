@@ -0,0 +1,194 @@
+package stats_service
+
+import (
+	"finance-manager-api-service/internal/client/operation_service/category"
+	"finance-manager-api-service/pkg/money"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+const (
+	GroupByNone     = ""
+	GroupByCategory = "category"
+	GroupByType     = "type"
+
+	IntervalNone  = ""
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+	IntervalYear  = "year"
+
+	categoryTypeIncome  = "income"
+	categoryTypeExpense = "expense"
+)
+
+func IsGroupBy(groupBy string) bool {
+	return groupBy == GroupByNone || groupBy == GroupByCategory || groupBy == GroupByType
+}
+
+func IsInterval(interval string) bool {
+	switch interval {
+	case IntervalNone, IntervalDay, IntervalWeek, IntervalMonth, IntervalYear:
+		return true
+	}
+	return false
+}
+
+// Signed returns amount of operation with sign of its category type: expenses are negative,
+// amounts of unknown type are left as they are
+func Signed(amount money.Amount, categoryType string) money.Amount {
+	if categoryType == categoryTypeExpense {
+		return amount.Neg()
+	}
+	return amount
+}
+
+type groupKey struct {
+	period       time.Time
+	categoryUUID string
+	categoryType string
+}
+
+// Aggregate computes Summary of the report and Groups by interval and groupBy, both are optional.
+// Categories of the user are keyed by UUID, they give names and types of operations' categories.
+// Report must be converted first.
+func (r *Report) Aggregate(groupBy, interval string, categories map[string]category.Category) error {
+	if !IsGroupBy(groupBy) {
+		return fmt.Errorf("unknown group by %q", groupBy)
+	}
+	if !IsInterval(interval) {
+		return fmt.Errorf("unknown interval %q", interval)
+	}
+
+	var summary statsBuilder
+	groups := make(map[groupKey]*statsBuilder)
+	for _, op := range r.Operations {
+		ctg := categories[op.CategoryUUID]
+		summary.add(op, ctg.Type)
+
+		if groupBy == GroupByNone && interval == IntervalNone {
+			continue
+		}
+		var key groupKey
+		if interval != IntervalNone {
+			key.period = periodStart(op.DateTime, interval)
+		}
+		switch groupBy {
+		case GroupByCategory:
+			key.categoryUUID = op.CategoryUUID
+			key.categoryType = ctg.Type
+		case GroupByType:
+			key.categoryType = ctg.Type
+		}
+		if groups[key] == nil {
+			groups[key] = &statsBuilder{}
+		}
+		groups[key].add(op, ctg.Type)
+	}
+
+	var err error
+	if r.Summary, err = summary.stats(); err != nil {
+		return err
+	}
+
+	r.Groups = make([]Group, 0, len(groups))
+	for key, builder := range groups {
+		group := Group{
+			CategoryUUID: key.categoryUUID,
+			CategoryName: categories[key.categoryUUID].Name,
+			CategoryType: key.categoryType,
+		}
+		if interval != IntervalNone {
+			start, end := key.period, periodEnd(key.period, interval)
+			group.PeriodStart, group.PeriodEnd = &start, &end
+		}
+		if group.Stats, err = builder.stats(); err != nil {
+			return err
+		}
+		r.Groups = append(r.Groups, group)
+	}
+
+	sort.Slice(r.Groups, func(i, j int) bool {
+		a, b := r.Groups[i], r.Groups[j]
+		if a.PeriodStart != nil && !a.PeriodStart.Equal(*b.PeriodStart) {
+			return a.PeriodStart.Before(*b.PeriodStart)
+		}
+		if a.CategoryType != b.CategoryType {
+			return a.CategoryType < b.CategoryType
+		}
+		if a.CategoryName != b.CategoryName {
+			return a.CategoryName < b.CategoryName
+		}
+		return a.CategoryUUID < b.CategoryUUID
+	})
+	return nil
+}
+
+type statsBuilder struct {
+	Stats
+}
+
+func (b *statsBuilder) add(op Operation, categoryType string) {
+	amount := op.ConvertedMoneySum
+	if b.Count == 0 || amount.Cmp(b.Min) < 0 {
+		b.Min = amount
+	}
+	if b.Count == 0 || amount.Cmp(b.Max) > 0 {
+		b.Max = amount
+	}
+	b.Count++
+	b.Sum = b.Sum.Add(amount)
+
+	switch categoryType {
+	case categoryTypeIncome:
+		b.Income = b.Income.Add(amount)
+	case categoryTypeExpense:
+		b.Expense = b.Expense.Add(amount)
+	}
+}
+
+func (b *statsBuilder) stats() (Stats, error) {
+	stats := b.Stats
+	stats.Net = stats.Income.Sub(stats.Expense)
+	if stats.Count > 0 {
+		average, err := stats.Sum.Mul(big.NewRat(1, int64(stats.Count)))
+		if err != nil {
+			return stats, fmt.Errorf("failed to compute average: %w", err)
+		}
+		stats.Average = average
+	}
+	return stats, nil
+}
+
+// periodStart truncates t in UTC to the start of its day, ISO week (from Monday), month or year
+func periodStart(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	year, month, day := t.Date()
+	switch interval {
+	case IntervalWeek:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+	case IntervalMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	case IntervalYear:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// periodEnd returns start of the next period, the end is exclusive
+func periodEnd(start time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return start.AddDate(0, 1, 0)
+	case IntervalYear:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,64 @@
+package stats_service
+
+import (
+	"context"
+	"finance-manager-api-service/pkg/exchange"
+	"finance-manager-api-service/pkg/money"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Convert sums operations up by currency and converts the sums to currency. Stats service
+// sums amounts as floats regardless of currency, so totals are always recomputed here.
+// Amount of every operation is converted too, aggregations are computed from converted amounts.
+func (r *Report) Convert(ctx context.Context, rates exchange.RateProvider, currency string) error {
+	sums := make(map[string]money.Amount)
+	for _, op := range r.Operations {
+		opCurrency := op.currencyOr(currency)
+		sums[opCurrency] = sums[opCurrency].Add(op.MoneySum)
+	}
+	currencyRates := make(map[string]*big.Rat, len(sums))
+
+	r.Currency = currency
+	r.TotalMoneySum = money.Amount{}
+	r.Totals = make([]CurrencyTotal, 0, len(sums))
+	for opCurrency, sum := range sums {
+		rate, err := rates.Rate(ctx, opCurrency, currency)
+		if err != nil {
+			return err
+		}
+		currencyRates[opCurrency] = rate
+		converted, err := sum.Mul(rate)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to %s: %w", opCurrency, currency, err)
+		}
+
+		r.TotalMoneySum = r.TotalMoneySum.Add(converted)
+		r.Totals = append(r.Totals, CurrencyTotal{
+			Currency:          opCurrency,
+			MoneySum:          sum,
+			ConvertedMoneySum: converted,
+		})
+	}
+
+	for i, op := range r.Operations {
+		converted, err := op.MoneySum.Mul(currencyRates[op.currencyOr(currency)])
+		if err != nil {
+			return fmt.Errorf("failed to convert operation %s to %s: %w", op.UUID, currency, err)
+		}
+		r.Operations[i].ConvertedMoneySum = converted
+	}
+
+	sort.Slice(r.Totals, func(i, j int) bool {
+		return r.Totals[i].Currency < r.Totals[j].Currency
+	})
+	return nil
+}
+
+func (op Operation) currencyOr(currency string) string {
+	if op.Currency == "" {
+		return currency
+	}
+	return op.Currency
+}
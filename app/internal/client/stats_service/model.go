@@ -1,16 +1,63 @@
 package stats_service
 
-import "time"
+import (
+	"finance-manager-api-service/internal/client/operation_service/category"
+	"finance-manager-api-service/pkg/money"
+	"time"
+)
 
+// Operation with empty currency is in base currency of the user
 type Operation struct {
-	UUID         string    `json:"uuid"`
-	CategoryUUID string    `json:"category_uuid"`
-	Description  string    `json:"description"`
-	MoneySum     float64   `json:"money_sum"`
-	DateTime     time.Time `json:"date_time"`
+	UUID         string       `json:"uuid"`
+	CategoryUUID string       `json:"category_uuid"`
+	Description  string       `json:"description"`
+	MoneySum     money.Amount `json:"money_sum" swaggertype:"number"`
+	Currency     string       `json:"currency,omitempty"`
+	DateTime     time.Time    `json:"date_time"`
+	// money sum in currency of the report, set by Report.Convert
+	ConvertedMoneySum money.Amount `json:"converted_money_sum" swaggertype:"number"`
+	// set only when requested with expand=category
+	Category *category.Info `json:"category,omitempty"`
 }
 
+// Report totals are converted to Currency, Totals breaks them down by currency of operations.
+// Summary and Groups are computed by Report.Aggregate, groups are present only when requested.
 type Report struct {
-	TotalMoneySum float64     `json:"total_money_sum"`
-	Operations    []Operation `json:"operations"`
+	Currency      string          `json:"currency"`
+	TotalMoneySum money.Amount    `json:"total_money_sum" swaggertype:"number"`
+	Totals        []CurrencyTotal `json:"totals"`
+	Summary       Stats           `json:"summary"`
+	Groups        []Group         `json:"groups,omitempty"`
+	Operations    []Operation     `json:"operations"`
+}
+
+type CurrencyTotal struct {
+	Currency          string       `json:"currency"`
+	MoneySum          money.Amount `json:"money_sum" swaggertype:"number"`
+	ConvertedMoneySum money.Amount `json:"converted_money_sum" swaggertype:"number"`
+}
+
+// Stats aggregates converted money sums of operations. Operations of unknown category count
+// in Sum but neither in Income nor in Expense.
+type Stats struct {
+	Count   int          `json:"count"`
+	Sum     money.Amount `json:"sum" swaggertype:"number"`
+	Average money.Amount `json:"average" swaggertype:"number"`
+	Min     money.Amount `json:"min" swaggertype:"number"`
+	Max     money.Amount `json:"max" swaggertype:"number"`
+	Income  money.Amount `json:"income" swaggertype:"number"`
+	Expense money.Amount `json:"expense" swaggertype:"number"`
+	// income minus expense
+	Net money.Amount `json:"net" swaggertype:"number"`
+}
+
+// Group is bucket of operations by period and category or category type, fields of grouping
+// that was not requested are empty
+type Group struct {
+	PeriodStart  *time.Time `json:"period_start,omitempty"`
+	PeriodEnd    *time.Time `json:"period_end,omitempty"`
+	CategoryUUID string     `json:"category_uuid,omitempty"`
+	CategoryName string     `json:"category_name,omitempty"`
+	CategoryType string     `json:"category_type,omitempty"`
+	Stats
 }
@@ -2,9 +2,13 @@ package stats_service
 
 import (
 	"context"
+	"encoding/json"
 	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/pkg/breaker"
 	"finance-manager-api-service/pkg/logging"
+	"finance-manager-api-service/pkg/metric"
 	"finance-manager-api-service/pkg/rest"
+	"finance-manager-api-service/pkg/utils"
 	"fmt"
 	"net/http"
 	"time"
@@ -13,7 +17,9 @@ import (
 const requestWaitTime = 5 * time.Second
 
 type Service interface {
-	GetReport(ctx context.Context, userUUID string, options []rest.FilterOptions) ([]byte, error)
+	GetReport(ctx context.Context, userUUID string, options []rest.FilterOptions) (Report, error)
+	// CheckHealth returns error when the stats service can not serve requests
+	CheckHealth(ctx context.Context) error
 }
 
 type client struct {
@@ -21,22 +27,24 @@ type client struct {
 	Resource string
 }
 
-func NewService(baseURL, resource string, logger *logging.Logger) Service {
+func NewService(baseURL, resource string, logger *logging.Logger, httpClient *http.Client,
+	retryPolicy rest.RetryPolicy, circuitBreaker *breaker.Breaker, upstreamMetrics *metric.Upstream) Service {
 	return &client{
 		Resource: resource,
 		base: rest.BaseClient{
-			BaseURL: baseURL,
-			HTTPClient: &http.Client{
-				Timeout: 10 * time.Second,
-			},
-			Logger: logger,
+			BaseURL:    baseURL,
+			HTTPClient: httpClient,
+			Logger:     logger,
+			Retry:      retryPolicy,
+			Breaker:    circuitBreaker,
+			Metrics:    upstreamMetrics,
 		},
 	}
 }
 
-func (c *client) GetReport(ctx context.Context, userUUID string, options []rest.FilterOptions) ([]byte, error) {
+func (c *client) GetReport(ctx context.Context, userUUID string, options []rest.FilterOptions) (Report, error) {
 	c.base.Logger.Info("Get stats report")
-	var report []byte
+	var report Report
 
 	c.base.Logger.Debug("build url")
 	url, err := c.base.BuildURL(c.Resource, options)
@@ -61,16 +69,20 @@ func (c *client) GetReport(ctx context.Context, userUUID string, options []rest.
 	}
 
 	if !response.IsOk {
-		appErr := apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
-		appErr.WithParams(apperror.ErrorParams(response.Error.Params))
-		appErr.WithFields(apperror.ErrorFields(response.Error.Fields))
-		return report, appErr
+		return report, apperror.FromAPIResponse(response)
 	}
-	c.base.Logger.Debug("read response body")
-	report, err = response.ReadBody()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body: %w", err)
+	c.base.Logger.Debug("decode response body")
+	defer utils.CloseBody(c.base.Logger, response.Body())
+	if err = json.NewDecoder(response.Body()).Decode(&report); err != nil {
+		return report, fmt.Errorf("failed to decode body: %w", err)
+	}
+	for i := range report.Operations {
+		report.Operations[i].DateTime = report.Operations[i].DateTime.UTC()
 	}
 	c.base.Logger.Debug("Get stats report successfully")
 	return report, nil
 }
+
+func (c *client) CheckHealth(ctx context.Context) error {
+	return c.base.Ping(ctx, rest.HeartbeatResource)
+}
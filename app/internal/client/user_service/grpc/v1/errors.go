@@ -9,14 +9,40 @@ import (
 func HandleGrpcServerError(err error) error {
 	st, ok := status.FromError(err)
 	if !ok {
-		return apperror.APIError("UNKNOWN", "An unknown error occurred", err.Error())
+		appErr := apperror.BadGatewayError(err.Error())
+		appErr.Code = "UNKNOWN"
+		return appErr
 	}
 	switch st.Code() {
-	case codes.InvalidArgument:
+	case codes.InvalidArgument, codes.OutOfRange:
 		return apperror.BadRequestError(st.Message())
 	case codes.NotFound:
 		return apperror.ErrNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return apperror.ConflictError(st.Message())
+	case codes.PermissionDenied:
+		return apperror.ForbiddenError(st.Message())
+	case codes.Unauthenticated:
+		return apperror.UnauthorizedError(st.Message())
+	case codes.FailedPrecondition:
+		return apperror.UnprocessableEntityError(st.Message())
+	case codes.ResourceExhausted:
+		return apperror.TooManyRequestsError(st.Message())
+	case codes.Unavailable:
+		return apperror.ServiceUnavailableError(st.Message())
+	case codes.DeadlineExceeded:
+		return apperror.GatewayTimeoutError(st.Message())
 	default:
-		return err
+		return apperror.BadGatewayError(st.Message())
 	}
 }
+
+// baseCurrencyNotSupported rejects base currency of the user, user-service contracts do not have
+// the field yet and the value would be silently dropped on the way to the service
+func baseCurrencyNotSupported() error {
+	appErr := apperror.UnprocessableEntityError("validation failed")
+	appErr.WithFields(apperror.ErrorFields{
+		"base_currency": "is not supported when user-service is connected with gRPC",
+	})
+	return appErr
+}
@@ -14,12 +14,13 @@ func NewCreateUserRequest(dto user_service.SignUpUserDTO) *protoUserService.Crea
 	}
 }
 
+// NewUserResponse leaves base currency empty, user-service contracts do not have it yet, so reports of
+// users are in the default currency. Client rejects base currency in create and update requests.
 func NewUserResponse(resp *protoUserService.UserResponse) user_service.User {
 	return user_service.User{
-		UUID:     resp.User.Uuid,
-		Name:     resp.User.Name,
-		Email:    resp.User.Email,
-		Password: resp.User.Password,
+		UUID:  resp.User.Uuid,
+		Name:  resp.User.Name,
+		Email: resp.User.Email,
 	}
 }
 
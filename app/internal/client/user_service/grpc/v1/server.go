@@ -3,11 +3,17 @@ package user_service_grpc
 import (
 	"context"
 	"finance-manager-api-service/internal/client/user_service"
+	"finance-manager-api-service/pkg/breaker"
 	"finance-manager-api-service/pkg/logging"
+	"finance-manager-api-service/pkg/metric"
+	"finance-manager-api-service/pkg/retry"
 	"fmt"
 	protoUserService "github.com/Anton9372/user-service-contracts/gen/go/user_service/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"strings"
 	"time"
 )
 
@@ -19,8 +25,18 @@ type client struct {
 	logger     *logging.Logger
 }
 
-func NewClient(grpcServerHostPort string, logger *logging.Logger) (user_service.UserService, error) {
-	conn, err := grpc.NewClient(grpcServerHostPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewClient connects to the user service. Calls failed with one of retryableCodes are retried
+// according to retryPolicy, except Create that would register the user twice. Circuit breaker
+// sees the outcome of all attempts of the call as one, metrics see every attempt.
+func NewClient(grpcServerHostPort string, logger *logging.Logger, retryPolicy retry.Policy,
+	retryableCodes []codes.Code, circuitBreaker *breaker.Breaker, upstreamMetrics *metric.Upstream) (user_service.UserService, error) {
+	conn, err := grpc.NewClient(grpcServerHostPort,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(
+			breaker.UnaryClientInterceptor(circuitBreaker),
+			retry.UnaryClientInterceptor(retryPolicy, retryableCodes, isIdempotent, upstreamMetrics.ObserveRetry),
+			upstreamMetrics.UnaryClientInterceptor(),
+		))
 	if err != nil {
 		logger.Fatalf("can not connect to gRPC server: %v", err)
 		return nil, fmt.Errorf("can not connect to gRPC server: %v", err)
@@ -35,8 +51,17 @@ func NewClient(grpcServerHostPort string, logger *logging.Logger) (user_service.
 	}, nil
 }
 
+// isIdempotent tells methods that are safe to retry by full method name like /user_service.UserService/Create.
+// Health checks are not retried, they must show the current state of the service.
+func isIdempotent(method string) bool {
+	return !strings.HasSuffix(method, "/Create") && method != grpc_health_v1.Health_Check_FullMethodName
+}
+
 func (c *client) Create(ctx context.Context, dto user_service.SignUpUserDTO) (user_service.User, error) {
 	c.logger.Debug("Create user")
+	if dto.BaseCurrency != "" {
+		return user_service.User{}, baseCurrencyNotSupported()
+	}
 	req := NewCreateUserRequest(dto)
 
 	reqCtx, cancel := context.WithTimeout(ctx, requestWaitTime)
@@ -44,7 +69,7 @@ func (c *client) Create(ctx context.Context, dto user_service.SignUpUserDTO) (us
 
 	resp, err := c.grpcClient.Create(reqCtx, req)
 	if err != nil {
-		c.logger.Error("failed to create user: %v", err)
+		c.logger.Errorf("failed to create user: %v", err)
 		return user_service.User{}, HandleGrpcServerError(err)
 	}
 
@@ -64,7 +89,7 @@ func (c *client) GetByUUID(ctx context.Context, uuid string) (user_service.User,
 
 	resp, err := c.grpcClient.GetByUUID(reqCtx, &protoUserService.GetByUUIDRequest{Uuid: uuid})
 	if err != nil {
-		c.logger.Error("failed to get user by uuid: %v", err)
+		c.logger.Errorf("failed to get user by uuid: %v", err)
 		return user_service.User{}, HandleGrpcServerError(err)
 	}
 	return NewUserResponse(resp), nil
@@ -79,7 +104,7 @@ func (c *client) GetByEmailAndPassword(ctx context.Context, email, password stri
 	resp, err := c.grpcClient.GetByEmailAndPassword(reqCtx,
 		&protoUserService.GetByEmailAndPasswordRequest{Email: email, Password: password})
 	if err != nil {
-		c.logger.Error("failed to get user by email and password: %v", err)
+		c.logger.Errorf("failed to get user by email and password: %v", err)
 		return user_service.User{}, HandleGrpcServerError(err)
 	}
 	return NewUserResponse(resp), nil
@@ -87,6 +112,9 @@ func (c *client) GetByEmailAndPassword(ctx context.Context, email, password stri
 
 func (c *client) Update(ctx context.Context, dto user_service.UpdateUserDTO) error {
 	c.logger.Debug("Update user")
+	if dto.BaseCurrency != nil {
+		return baseCurrencyNotSupported()
+	}
 	req := NewUpdateUserRequest(dto)
 
 	reqCtx, cancel := context.WithTimeout(ctx, requestWaitTime)
@@ -94,7 +122,7 @@ func (c *client) Update(ctx context.Context, dto user_service.UpdateUserDTO) err
 
 	_, err := c.grpcClient.Update(reqCtx, req)
 	if err != nil {
-		c.logger.Error("failed to update user: %v", err)
+		c.logger.Errorf("failed to update user: %v", err)
 		return HandleGrpcServerError(err)
 	}
 	return nil
@@ -108,8 +136,20 @@ func (c *client) Delete(ctx context.Context, uuid string) error {
 
 	_, err := c.grpcClient.Delete(reqCtx, &protoUserService.DeleteRequest{Uuid: uuid})
 	if err != nil {
-		c.logger.Error("failed to delete user: %v", err)
+		c.logger.Errorf("failed to delete user: %v", err)
 		return HandleGrpcServerError(err)
 	}
 	return nil
 }
+
+// CheckHealth asks the server with gRPC health checking protocol
+func (c *client) CheckHealth(ctx context.Context) error {
+	resp, err := grpc_health_v1.NewHealthClient(c.Conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if resp.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		return fmt.Errorf("service status is %s", resp.GetStatus())
+	}
+	return nil
+}
@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"finance-manager-api-service/internal/apperror"
 	"finance-manager-api-service/internal/client/user_service"
+	"finance-manager-api-service/pkg/breaker"
 	"finance-manager-api-service/pkg/logging"
+	"finance-manager-api-service/pkg/metric"
 	"finance-manager-api-service/pkg/rest"
 	"finance-manager-api-service/pkg/utils"
 	"fmt"
@@ -22,15 +24,17 @@ type client struct {
 	Resource string
 }
 
-func NewService(baseURL string, resource string, logger *logging.Logger) user_service.UserService {
+func NewService(baseURL string, resource string, logger *logging.Logger, httpClient *http.Client,
+	retryPolicy rest.RetryPolicy, circuitBreaker *breaker.Breaker, upstreamMetrics *metric.Upstream) user_service.UserService {
 	return &client{
 		Resource: resource,
 		base: rest.BaseClient{
-			BaseURL: baseURL,
-			HTTPClient: &http.Client{
-				Timeout: 10 * time.Second,
-			},
-			Logger: logger,
+			BaseURL:    baseURL,
+			HTTPClient: httpClient,
+			Logger:     logger,
+			Retry:      retryPolicy,
+			Breaker:    circuitBreaker,
+			Metrics:    upstreamMetrics,
 		},
 	}
 }
@@ -68,7 +72,7 @@ func (c *client) Create(ctx context.Context, dto user_service.SignUpUserDTO) (us
 	}
 
 	if !response.IsOk {
-		return user, apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return user, apperror.FromAPIResponse(response)
 	}
 	c.base.Logger.Debug("parse location header")
 	userURL, err := response.Location()
@@ -110,7 +114,7 @@ func (c *client) GetByUUID(ctx context.Context, uuid string) (user_service.User,
 	}
 
 	if !response.IsOk {
-		return user, apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return user, apperror.FromAPIResponse(response)
 	}
 	defer utils.CloseBody(c.base.Logger, response.Body())
 	if err = json.NewDecoder(response.Body()).Decode(&user); err != nil {
@@ -157,7 +161,7 @@ func (c *client) GetByEmailAndPassword(ctx context.Context, email, password stri
 	}
 
 	if !response.IsOk {
-		return user, apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return user, apperror.FromAPIResponse(response)
 	}
 	defer utils.CloseBody(c.base.Logger, response.Body())
 	if err = json.NewDecoder(response.Body()).Decode(&user); err != nil {
@@ -198,7 +202,7 @@ func (c *client) Update(ctx context.Context, dto user_service.UpdateUserDTO) err
 	}
 
 	if !response.IsOk {
-		return apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return apperror.FromAPIResponse(response)
 	}
 	return nil
 }
@@ -229,7 +233,11 @@ func (c *client) Delete(ctx context.Context, uuid string) error {
 	}
 
 	if !response.IsOk {
-		return apperror.APIError(response.Error.Code, response.Error.Message, response.Error.DeveloperMessage)
+		return apperror.FromAPIResponse(response)
 	}
 	return nil
 }
+
+func (c *client) CheckHealth(ctx context.Context) error {
+	return c.base.Ping(ctx, rest.HeartbeatResource)
+}
@@ -8,4 +8,6 @@ type UserService interface {
 	GetByEmailAndPassword(ctx context.Context, email, password string) (User, error)
 	Update(ctx context.Context, dto UpdateUserDTO) error
 	Delete(ctx context.Context, uuid string) error
+	// CheckHealth returns error when the user service can not serve requests
+	CheckHealth(ctx context.Context) error
 }
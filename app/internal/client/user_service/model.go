@@ -1,29 +1,31 @@
 package user_service
 
 type User struct {
-	UUID     string `json:"uuid"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password" `
+	UUID         string `json:"uuid"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	BaseCurrency string `json:"base_currency,omitempty"`
 }
 
 type SignInUserDTO struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
 }
 
 type SignUpUserDTO struct {
-	Name             string `json:"name"`
-	Email            string `json:"email"`
-	Password         string `json:"password"`
-	RepeatedPassword string `json:"repeated_password"`
+	Name             string `json:"name" validate:"required,max=100"`
+	Email            string `json:"email" validate:"required,email,max=254"`
+	Password         string `json:"password" validate:"required,min=8,max=72"`
+	RepeatedPassword string `json:"repeated_password" validate:"required,eqfield=Password"`
+	BaseCurrency     string `json:"base_currency,omitempty" validate:"omitempty,currency"`
 }
 
 type UpdateUserDTO struct {
-	UUID             string  `json:"uuid"`
-	Name             *string `json:"name"`
-	Email            *string `json:"email"`
+	UUID             string  `json:"uuid" validate:"required,uuid"`
+	Name             *string `json:"name" validate:"omitempty,required,max=100"`
+	Email            *string `json:"email" validate:"omitempty,email,max=254"`
 	Password         string  `json:"password"`
-	NewPassword      *string `json:"new_password"`
-	RepeatedPassword *string `json:"repeated_new_password"`
+	NewPassword      *string `json:"new_password" validate:"omitempty,min=8,max=72"`
+	RepeatedPassword *string `json:"repeated_new_password" validate:"required_with=NewPassword,eqfield=NewPassword"`
+	BaseCurrency     *string `json:"base_currency" validate:"omitempty,currency"`
 }
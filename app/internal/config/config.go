@@ -2,14 +2,67 @@ package config
 
 import (
 	"finance-manager-api-service/pkg/logging"
+	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
 	"sync"
+	"time"
 )
 
 type Config struct {
 	JWT struct {
-		Secret string `yaml:"secret" env-required:"true"`
+		Algorithm        string `yaml:"algorithm" env-default:"HS256"`
+		Secret           string `yaml:"secret"`
+		KeyID            string `yaml:"key_id"`
+		PrivateKeyFile   string `yaml:"private_key_file"`
+		VerificationKeys []struct {
+			KeyID         string `yaml:"key_id"`
+			Algorithm     string `yaml:"algorithm"`
+			PublicKeyFile string `yaml:"public_key_file"`
+		} `yaml:"verification_keys"`
+		RefreshTokenAbsoluteTTL time.Duration `yaml:"refresh_token_absolute_ttl" env-default:"720h"`
+		RefreshTokenIdleTTL     time.Duration `yaml:"refresh_token_idle_ttl" env-default:"168h"`
 	}
+	RefreshTokenStore struct {
+		Type   string `yaml:"type" env-default:"memory"`
+		Memory struct {
+			Size int `yaml:"size" env-default:"104857600"`
+		} `yaml:"memory"`
+		Bolt struct {
+			Path string `yaml:"path" env-default:"data/refresh_tokens.db"`
+		} `yaml:"bolt"`
+		Redis struct {
+			Addr      string `yaml:"addr"`
+			Password  string `yaml:"password" env:"REFRESH_TOKEN_STORE_REDIS_PASSWORD"`
+			DB        int    `yaml:"db"`
+			KeyPrefix string `yaml:"key_prefix" env-default:"refresh_token:"`
+		} `yaml:"redis"`
+	} `yaml:"refresh_token_store"`
+	RateLimit struct {
+		Auth struct {
+			IP    RateLimitRule `yaml:"ip"`
+			Email RateLimitRule `yaml:"email"`
+		} `yaml:"auth"`
+		LoginLockout struct {
+			MaxFailures   int           `yaml:"max_failures" env-default:"5"`
+			BaseDuration  time.Duration `yaml:"base_duration" env-default:"1m"`
+			MaxDuration   time.Duration `yaml:"max_duration" env-default:"1h"`
+			FailureWindow time.Duration `yaml:"failure_window" env-default:"15m"`
+		} `yaml:"login_lockout"`
+	} `yaml:"rate_limit"`
+	Currency struct {
+		// base currency of users that have not chosen one
+		Default string `yaml:"default" env-default:"USD"`
+		// .csv with "from,to,rate" lines or .json like {"base": "USD", "rates": {"EUR": "0.92"}}
+		RatesFile string `yaml:"rates_file"`
+		// number or string: encoding of amounts in responses, strings keep amounts exact for clients
+		// that parse JSON numbers as float64
+		AmountFormat string `yaml:"amount_format" env-default:"number"`
+	} `yaml:"currency"`
+	ErrorResponse struct {
+		// json renders AppError as is, problem renders RFC 7807 problem details to every client
+		Format             string `yaml:"format" env-default:"json"`
+		ProblemTypeBaseURI string `yaml:"problem_type_base_uri" env-default:"/errors/"`
+	} `yaml:"error_response"`
 	HTTP struct {
 		IP   string `yaml:"ip"`
 		Port int    `yaml:"port"`
@@ -21,19 +74,86 @@ type Config struct {
 			ExposedHeaders   []string `yaml:"exposed_headers"`
 		} `yaml:"cors"`
 	} `yaml:"http"`
+	// Health configures /api/health/ready that checks downstream services
+	Health struct {
+		Timeout  time.Duration `yaml:"timeout" env-default:"2s"`
+		CacheTTL time.Duration `yaml:"cache_ttl" env-default:"5s"`
+	} `yaml:"health"`
+	// HTTPClient configures connection pool shared by HTTP clients of upstream services
+	HTTPClient struct {
+		Timeout             time.Duration `yaml:"timeout" env-default:"10s"`
+		MaxIdleConns        int           `yaml:"max_idle_conns" env-default:"100"`
+		MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host" env-default:"32"`
+		MaxConnsPerHost     int           `yaml:"max_conns_per_host" env-default:"0"`
+		IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout" env-default:"90s"`
+		KeepAlive           time.Duration `yaml:"keep_alive" env-default:"30s"`
+		DialTimeout         time.Duration `yaml:"dial_timeout" env-default:"5s"`
+		TLSHandshakeTimeout time.Duration `yaml:"tls_handshake_timeout" env-default:"5s"`
+	} `yaml:"http_client"`
 	UserService struct {
-		HttpUrl         string `yaml:"http_url" env-required:"true"`
-		GrpcUrl         string `yaml:"grpc_url" env-required:"true"`
-		ConnectWithGRPC bool   `yaml:"connect_with_grpc"`
+		HttpUrl         string         `yaml:"http_url" env-required:"true"`
+		GrpcUrl         string         `yaml:"grpc_url" env-required:"true"`
+		ConnectWithGRPC bool           `yaml:"connect_with_grpc"`
+		Retry           RetryPolicy    `yaml:"retry"`
+		CircuitBreaker  CircuitBreaker `yaml:"circuit_breaker"`
 	} `yaml:"user_service" env-required:"true"`
 	OperationService struct {
-		URL string `yaml:"url" env-required:"true"`
+		URL            string         `yaml:"url" env-required:"true"`
+		Retry          RetryPolicy    `yaml:"retry"`
+		CircuitBreaker CircuitBreaker `yaml:"circuit_breaker"`
+		Batch          struct {
+			MaxItems    int `yaml:"max_items" env-default:"100"`
+			Concurrency int `yaml:"concurrency" env-default:"8"`
+		} `yaml:"batch"`
+		Import struct {
+			MaxFileSize     int64 `yaml:"max_file_size" env-default:"5242880"`
+			MaxTransactions int   `yaml:"max_transactions" env-default:"1000"`
+		} `yaml:"import"`
 	} `yaml:"operation_service" env-required:"true"`
 	StatsService struct {
-		URL string `yaml:"url" env-required:"true"`
+		URL            string         `yaml:"url" env-required:"true"`
+		Retry          RetryPolicy    `yaml:"retry"`
+		CircuitBreaker CircuitBreaker `yaml:"circuit_breaker"`
 	} `yaml:"stats_service" env-required:"true"`
 }
 
+// RateLimitRule allows Requests per Period on average with bursts up to Burst requests
+type RateLimitRule struct {
+	Requests int           `yaml:"requests" env-default:"10"`
+	Period   time.Duration `yaml:"period" env-default:"1m"`
+	Burst    int           `yaml:"burst" env-default:"10"`
+}
+
+// validate rejects limits that give zero or undefined rate of the token bucket
+func (r RateLimitRule) validate(name string) error {
+	if r.Requests <= 0 || r.Period <= 0 || r.Burst <= 0 {
+		return fmt.Errorf("rate_limit.%s: requests, period and burst must be positive, got %d per %s with burst %d",
+			name, r.Requests, r.Period, r.Burst)
+	}
+	return nil
+}
+
+// RetryPolicy of calls to downstream service, MaxAttempts 1 disables retries. Requests that
+// are not idempotent are never retried.
+type RetryPolicy struct {
+	MaxAttempts int           `yaml:"max_attempts" env-default:"3"`
+	BaseDelay   time.Duration `yaml:"base_delay" env-default:"100ms"`
+	MaxDelay    time.Duration `yaml:"max_delay" env-default:"1s"`
+	// HTTP statuses retried by REST clients
+	RetryableStatuses []int `yaml:"retryable_statuses" env-default:"429,502,503,504"`
+	// names of gRPC codes retried by gRPC clients
+	RetryableCodes []string `yaml:"retryable_codes" env-default:"UNAVAILABLE"`
+}
+
+// CircuitBreaker opens after FailureThreshold consecutive failed calls to downstream service and
+// rejects calls for OpenTimeout, then lets HalfOpenRequests trial calls through. Negative
+// threshold disables the breaker, zero is replaced with default.
+type CircuitBreaker struct {
+	FailureThreshold int           `yaml:"failure_threshold" env-default:"5"`
+	OpenTimeout      time.Duration `yaml:"open_timeout" env-default:"30s"`
+	HalfOpenRequests int           `yaml:"half_open_requests" env-default:"1"`
+}
+
 var instance *Config
 var once sync.Once
 
@@ -47,6 +167,24 @@ func GetConfig() *Config {
 			logger.Info(help)
 			logger.Fatal(err)
 		}
+		if err := instance.validate(); err != nil {
+			logger.Fatal(err)
+		}
 	})
 	return instance
 }
+
+// validate checks values that can not be fixed by defaults of cleanenv
+func (c *Config) validate() error {
+	if err := c.RateLimit.Auth.IP.validate("auth.ip"); err != nil {
+		return err
+	}
+	if err := c.RateLimit.Auth.Email.validate("auth.email"); err != nil {
+		return err
+	}
+	lockout := c.RateLimit.LoginLockout
+	if lockout.MaxFailures <= 0 || lockout.BaseDuration <= 0 || lockout.MaxDuration <= 0 || lockout.FailureWindow <= 0 {
+		return fmt.Errorf("rate_limit.login_lockout: max_failures and durations must be positive")
+	}
+	return nil
+}
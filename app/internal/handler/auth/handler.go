@@ -1,40 +1,79 @@
 package auth
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"finance-manager-api-service/internal/apperror"
 	"finance-manager-api-service/internal/client/user_service"
 	h "finance-manager-api-service/internal/handler"
 	"finance-manager-api-service/pkg/jwt"
 	"finance-manager-api-service/pkg/logging"
+	"finance-manager-api-service/pkg/ratelimit"
 	"finance-manager-api-service/pkg/utils"
+	"finance-manager-api-service/pkg/validate"
 	"github.com/julienschmidt/httprouter"
+	"io"
 	"net/http"
+	"strings"
 )
 
 const (
-	authURL   = "/api/auth"
-	signUpURL = "/api/signup"
+	authURL      = "/api/auth"
+	logoutURL    = "/api/auth/logout"
+	logoutAllURL = "/api/auth/logout-all"
+	signUpURL    = "/api/signup"
 )
 
+const maxAuthBodySize = 1 << 20
+
 type handler struct {
-	Logger      *logging.Logger
-	UserService user_service.UserService
-	JWTHelper   jwt.Helper
+	Logger       *logging.Logger
+	UserService  user_service.UserService
+	JWTHelper    jwt.Helper
+	IPLimiter    *ratelimit.Limiter
+	EmailLimiter *ratelimit.Limiter
+	Lockout      ratelimit.Lockout
 }
 
-func NewAuthHandler(logger *logging.Logger, userService user_service.UserService, jwtHelper jwt.Helper) h.Handler {
+func NewAuthHandler(logger *logging.Logger, userService user_service.UserService, jwtHelper jwt.Helper,
+	ipLimiter, emailLimiter *ratelimit.Limiter, lockout ratelimit.Lockout) h.Handler {
 	return &handler{
-		Logger:      logger,
-		UserService: userService,
-		JWTHelper:   jwtHelper,
+		Logger:       logger,
+		UserService:  userService,
+		JWTHelper:    jwtHelper,
+		IPLimiter:    ipLimiter,
+		EmailLimiter: emailLimiter,
+		Lockout:      lockout,
 	}
 }
 
 func (h *handler) Register(router *httprouter.Router) {
-	router.HandlerFunc(http.MethodPost, authURL, apperror.Middleware(h.Auth))
-	router.HandlerFunc(http.MethodPut, authURL, apperror.Middleware(h.Auth))
-	router.HandlerFunc(http.MethodPost, signUpURL, apperror.Middleware(h.SignUp))
+	router.HandlerFunc(http.MethodPost, authURL,
+		h.IPLimiter.Middleware(ratelimit.ByIP, h.EmailLimiter.Middleware(emailKey, apperror.Middleware(h.Auth))))
+	router.HandlerFunc(http.MethodPut, authURL, h.IPLimiter.Middleware(ratelimit.ByIP, apperror.Middleware(h.Auth)))
+	router.HandlerFunc(http.MethodPost, logoutURL, h.IPLimiter.Middleware(ratelimit.ByIP, apperror.Middleware(h.Logout)))
+	router.HandlerFunc(http.MethodPost, logoutAllURL, jwt.Middleware(apperror.Middleware(h.LogoutAll)))
+	router.HandlerFunc(http.MethodPost, signUpURL, h.IPLimiter.Middleware(ratelimit.ByIP, apperror.Middleware(h.SignUp)))
+}
+
+// emailKey limits sign in attempts per account. Body is read and put back for the handler.
+func emailKey(r *http.Request) string {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxAuthBodySize))
+	if err != nil {
+		return ""
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var dto user_service.SignInUserDTO
+	if err = json.Unmarshal(body, &dto); err != nil {
+		return ""
+	}
+	return normalizeEmail(dto.Email)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 // SignUp
@@ -45,7 +84,8 @@ func (h *handler) Register(router *httprouter.Router) {
 // @Produce 	json
 // @Param 		input	body 	 user_service.SignUpUserDTO	true	"User's data"
 // @Success 	201 	{object} jwt.TokenAndRefreshToken
-// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	400 	{object} apperror.AppError "Invalid JSON body"
+// @Failure 	422 	{object} apperror.AppError "Validation error, base_currency is rejected when user-service is connected with gRPC"
 // @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
 // @Failure 	500 	{object} apperror.AppError "Internal server error"
 // @Router /signup [post]
@@ -58,13 +98,16 @@ func (h *handler) SignUp(w http.ResponseWriter, r *http.Request) error {
 	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
 		return apperror.BadRequestError("invalid JSON body")
 	}
+	if err := validate.Struct(dto); err != nil {
+		return err
+	}
 
 	user, err := h.UserService.Create(r.Context(), dto)
 	if err != nil {
 		return err
 	}
 
-	token, err := h.JWTHelper.GenerateAccessToken(user)
+	token, err := h.JWTHelper.GenerateAccessToken(user, jwt.NewClient(r))
 	if err != nil {
 		return err
 	}
@@ -84,7 +127,9 @@ func (h *handler) SignUp(w http.ResponseWriter, r *http.Request) error {
 // @Param       token        body       jwt.RefreshToken			false    "RefreshToken"
 // @Success 	201 		{object} 	jwt.TokenAndRefreshToken
 // @Failure     400         {object}    apperror.AppError   "Bad request or invalid JSON body"
+// @Failure     422         {object}    apperror.AppError   "Validation error"
 // @Failure     401         {object}    apperror.AppError   "Unauthorized: invalid credentials"
+// @Failure     429         {object}    apperror.AppError   "Too many requests or account is temporarily locked"
 // @Failure     500         {object}    apperror.AppError   "Internal server error"
 // @Router      /auth       [post]
 // @Router      /auth       [put]
@@ -100,13 +145,35 @@ func (h *handler) Auth(w http.ResponseWriter, r *http.Request) error {
 		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
 			return apperror.BadRequestError("invalid JSON body")
 		}
+		if err := validate.Struct(dto); err != nil {
+			return err
+		}
+
+		email := normalizeEmail(dto.Email)
+		lockedFor, err := h.Lockout.Check(email)
+		if err != nil {
+			return err
+		}
+		if lockedFor > 0 {
+			h.Logger.Warnf("sign in attempt for locked account %s", email)
+			ratelimit.TooManyRequests(w, r, lockedFor)
+			return nil
+		}
 
 		user, err := h.UserService.GetByEmailAndPassword(r.Context(), dto.Email, dto.Password)
 		if err != nil {
+			// only rejections from user service are failed attempts, not upstream failures
+			var appErr *apperror.AppError
+			if errors.As(err, &appErr) && appErr.HTTPStatus() < http.StatusInternalServerError {
+				if _, lockErr := h.Lockout.RecordFailure(email); lockErr != nil {
+					h.Logger.Error(lockErr)
+				}
+			}
 			return err
 		}
+		h.Lockout.Reset(email)
 
-		token, err = h.JWTHelper.GenerateAccessToken(user)
+		token, err = h.JWTHelper.GenerateAccessToken(user, jwt.NewClient(r))
 		if err != nil {
 			return err
 		}
@@ -118,7 +185,7 @@ func (h *handler) Auth(w http.ResponseWriter, r *http.Request) error {
 			return apperror.BadRequestError("failed to decode token")
 		}
 
-		token, err = h.JWTHelper.UpdateRefreshToken(rt)
+		token, err = h.JWTHelper.UpdateRefreshToken(rt, jwt.NewClient(r))
 		if err != nil {
 			return err
 		}
@@ -127,3 +194,65 @@ func (h *handler) Auth(w http.ResponseWriter, r *http.Request) error {
 	_, _ = w.Write(token)
 	return nil
 }
+
+// Logout
+// @Summary     Logout
+// @Description Revokes presented refresh token and all tokens rotated from it.
+// @Description Access token from Authorization header is revoked too, if it is present.
+// @Tags        Auth
+// @Accept      json
+// @Param       token        body       jwt.RefreshToken	true    "RefreshToken"
+// @Success 	204
+// @Failure     400         {object}    apperror.AppError   "Invalid JSON body"
+// @Failure     500         {object}    apperror.AppError   "Internal server error"
+// @Router      /auth/logout [post]
+func (h *handler) Logout(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("Logout")
+	w.Header().Set("Content-Type", "application/json")
+	defer utils.CloseBody(h.Logger, r.Body)
+
+	var rt jwt.RefreshToken
+	if err := json.NewDecoder(r.Body).Decode(&rt); err != nil {
+		return apperror.BadRequestError("failed to decode token")
+	}
+
+	if err := h.JWTHelper.RevokeRefreshToken(rt); err != nil {
+		return err
+	}
+
+	if claims, err := jwt.ClaimsFromRequest(r); err == nil {
+		if err = h.JWTHelper.RevokeAccessToken(claims); err != nil {
+			return err
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// LogoutAll
+// @Summary     Logout from all devices
+// @Description Revokes every access and refresh token of the user
+// @Security	JWTAuth
+// @Tags        Auth
+// @Success 	204
+// @Failure 	401 		   						"Unauthorized"
+// @Failure     500         {object}    apperror.AppError   "Internal server error"
+// @Router      /auth/logout-all [post]
+func (h *handler) LogoutAll(w http.ResponseWriter, r *http.Request) error {
+	h.Logger.Info("Logout from all devices")
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Context().Value("user_uuid") == nil {
+		h.Logger.Error("no user_uuid in context")
+		return apperror.UnauthorizedError("")
+	}
+	userUUID := r.Context().Value("user_uuid").(string)
+
+	if err := h.JWTHelper.RevokeUserSessions(userUUID); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
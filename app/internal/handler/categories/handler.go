@@ -5,9 +5,12 @@ import (
 	"finance-manager-api-service/internal/apperror"
 	"finance-manager-api-service/internal/client/operation_service/category"
 	h "finance-manager-api-service/internal/handler"
+	"finance-manager-api-service/internal/ownership"
 	"finance-manager-api-service/pkg/jwt"
 	"finance-manager-api-service/pkg/logging"
+	"finance-manager-api-service/pkg/rest"
 	"finance-manager-api-service/pkg/utils"
+	"finance-manager-api-service/pkg/validate"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
@@ -21,12 +24,15 @@ const (
 type categoryHandler struct {
 	Logger          *logging.Logger
 	CategoryService category.Service
+	Ownership       ownership.Checker
 }
 
-func NewCategoryHandler(logger *logging.Logger, categoryService category.Service) h.Handler {
+func NewCategoryHandler(logger *logging.Logger, categoryService category.Service,
+	ownershipChecker ownership.Checker) h.Handler {
 	return &categoryHandler{
 		Logger:          logger,
 		CategoryService: categoryService,
+		Ownership:       ownershipChecker,
 	}
 }
 
@@ -44,9 +50,11 @@ func (h *categoryHandler) Register(router *httprouter.Router) {
 // @Tags 		Category
 // @Accept		json
 // @Param 		input	body 	 category.CreateCategoryDTO	true	"Category data"
+// @Param 		Idempotency-Key	header	string	false	"Repeated request with the same key does not create category twice"
 // @Success 	201
 // @Failure 	401 		   						"Unauthorized"
-// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	400 	{object} apperror.AppError "Invalid JSON body"
+// @Failure 	422 	{object} apperror.AppError "Validation error"
 // @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
 // @Failure 	500 	{object} apperror.AppError "Internal server error"
 // @Router /categories [post]
@@ -65,8 +73,12 @@ func (h *categoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 		return apperror.BadRequestError("invalid JSON body")
 	}
 	createdCategory.UserUUID = userUUID
+	if err := validate.Struct(createdCategory); err != nil {
+		return err
+	}
 
-	categoryUUID, err := h.CategoryService.Create(r.Context(), createdCategory)
+	ctx := rest.WithIdempotencyKey(r.Context(), rest.IdempotencyKey(r))
+	categoryUUID, err := h.CategoryService.Create(ctx, createdCategory)
 	if err != nil {
 		return err
 	}
@@ -117,7 +129,8 @@ func (h *categoryHandler) GetCategories(w http.ResponseWriter, r *http.Request)
 // @Param 		uuid 		path 	 string 					true  "Category's uuid"
 // @Param 		input 		body 	 category.UpdateCategoryDTO true  "Category's data"
 // @Success 	204
-// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	400 	{object} apperror.AppError "Invalid JSON body"
+// @Failure 	422 	{object} apperror.AppError "Validation error"
 // @Failure 	401 		   					   "Unauthorized"
 // @Failure 	404 	{object} apperror.AppError "Category is not found"
 // @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
@@ -126,6 +139,12 @@ func (h *categoryHandler) GetCategories(w http.ResponseWriter, r *http.Request)
 func (h *categoryHandler) PartiallyUpdateCategory(w http.ResponseWriter, r *http.Request) error {
 	w.Header().Set("Content-Type", "application/json")
 
+	if r.Context().Value("user_uuid") == nil {
+		h.Logger.Error("no user_uuid in context")
+		return apperror.UnauthorizedError("")
+	}
+	userUUID := r.Context().Value("user_uuid").(string)
+
 	var updatedCategory category.UpdateCategoryDTO
 	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
 	categoryUUID := params.ByName("uuid")
@@ -133,8 +152,15 @@ func (h *categoryHandler) PartiallyUpdateCategory(w http.ResponseWriter, r *http
 	if err := json.NewDecoder(r.Body).Decode(&updatedCategory); err != nil {
 		return apperror.BadRequestError("invalid JSON body")
 	}
-
 	updatedCategory.UUID = categoryUUID
+	if err := validate.Struct(updatedCategory); err != nil {
+		return err
+	}
+
+	if err := h.Ownership.CheckCategory(r.Context(), userUUID, categoryUUID); err != nil {
+		return err
+	}
+
 	err := h.CategoryService.Update(r.Context(), updatedCategory)
 	if err != nil {
 		return err
@@ -159,9 +185,19 @@ func (h *categoryHandler) PartiallyUpdateCategory(w http.ResponseWriter, r *http
 func (h *categoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) error {
 	w.Header().Set("Content-Type", "application/json")
 
+	if r.Context().Value("user_uuid") == nil {
+		h.Logger.Error("no user_uuid in context")
+		return apperror.UnauthorizedError("")
+	}
+	userUUID := r.Context().Value("user_uuid").(string)
+
 	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
 	categoryUUID := params.ByName("uuid")
 
+	if err := h.Ownership.CheckCategory(r.Context(), userUUID, categoryUUID); err != nil {
+		return err
+	}
+
 	err := h.CategoryService.Delete(r.Context(), categoryUUID)
 	if err != nil {
 		return err
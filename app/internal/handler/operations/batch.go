@@ -0,0 +1,189 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/internal/client/operation_service/operation"
+	"finance-manager-api-service/pkg/rest"
+	"finance-manager-api-service/pkg/utils"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+const (
+	batchURL = "/api/operations/batch"
+
+	batchActionCreate = "create"
+	batchActionUpdate = "update"
+	batchActionDelete = "delete"
+)
+
+// BatchLimits bounds size of batch request and number of its items executed at the same time
+type BatchLimits struct {
+	MaxItems    int
+	Concurrency int
+}
+
+type BatchRequest struct {
+	Items []BatchItem `json:"items"`
+}
+
+// BatchItem is create, update or delete of single operation. UUID is required for update and delete,
+// Operation is operation.CreateOperationDTO for create and operation.UpdateOperationDTO for update.
+type BatchItem struct {
+	Action    string          `json:"action" enums:"create,update,delete"`
+	UUID      string          `json:"uuid,omitempty"`
+	Operation json.RawMessage `json:"operation,omitempty" swaggertype:"object"`
+}
+
+// BatchResult is outcome of the item with the same index, Status is HTTP status the item
+// would get as a single request
+type BatchResult struct {
+	Index  int                `json:"index"`
+	Status int                `json:"status"`
+	UUID   string             `json:"uuid,omitempty"`
+	Error  *apperror.AppError `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// ExecuteBatch
+// @Summary 	Create, update and delete operations in batch
+// @Description Executes items independently, failure of one item does not roll back others.
+// @Description Result of every item is reported with status it would get as a single request.
+// @Security	JWTAuth
+// @Tags 		Operation
+// @Accept		json
+// @Produce 	json
+// @Param 		input	body 	 BatchRequest	true	"Batch items"
+// @Param 		Idempotency-Key	header	string	false	"Repeated batch with the same key does not create operations twice"
+// @Success 	200 	{object} BatchResponse "Results of items"
+// @Failure 	401 		   					"Unauthorized"
+// @Failure 	400 	{object} apperror.AppError "Invalid JSON body"
+// @Failure 	422 	{object} apperror.AppError "Batch is empty or too large"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router /operations/batch [post]
+func (h *operationHandler) ExecuteBatch(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	defer utils.CloseBody(h.Logger, r.Body)
+
+	if r.Context().Value("user_uuid") == nil {
+		h.Logger.Error("no user_uuid in context")
+		return apperror.UnauthorizedError("")
+	}
+	userUUID := r.Context().Value("user_uuid").(string)
+
+	var batch BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		return apperror.BadRequestError("invalid JSON body")
+	}
+	if len(batch.Items) == 0 || len(batch.Items) > h.Batch.MaxItems {
+		appErr := apperror.UnprocessableEntityError("validation failed")
+		appErr.WithFields(apperror.ErrorFields{
+			"items": "must contain from 1 to " + strconv.Itoa(h.Batch.MaxItems) + " items",
+		})
+		return appErr
+	}
+
+	h.Logger.Infof("execute batch of %d operations", len(batch.Items))
+	response := BatchResponse{
+		Results: make([]BatchResult, len(batch.Items)),
+	}
+
+	h.runConcurrently(len(batch.Items), func(i int) {
+		ctx := rest.WithIdempotencyKey(r.Context(), rest.ItemIdempotencyKey(r, i))
+		response.Results[i] = h.executeBatchItem(ctx, userUUID, batch.Items[i])
+		response.Results[i].Index = i
+	})
+
+	for _, result := range response.Results {
+		if result.Error != nil {
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+	}
+
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(responseBytes)
+	return nil
+}
+
+func (h *operationHandler) executeBatchItem(ctx context.Context, userUUID string, item BatchItem) BatchResult {
+	if item.Action != batchActionCreate {
+		if _, err := uuid.Parse(item.UUID); err != nil {
+			return failedBatchItem(invalidField("uuid", "must be a valid uuid"))
+		}
+	}
+
+	switch item.Action {
+	case batchActionCreate:
+		var dto operation.CreateOperationDTO
+		if err := decodeOperationDTO(bytes.NewReader(item.Operation), &dto); err != nil {
+			return failedBatchItem(err)
+		}
+		operationUUID, err := h.createOperation(ctx, userUUID, dto)
+		if err != nil {
+			return failedBatchItem(err)
+		}
+		return BatchResult{Status: http.StatusCreated, UUID: operationUUID}
+	case batchActionUpdate:
+		var dto operation.UpdateOperationDTO
+		if err := decodeOperationDTO(bytes.NewReader(item.Operation), &dto); err != nil {
+			return failedBatchItem(err)
+		}
+		if err := h.updateOperation(ctx, userUUID, item.UUID, dto); err != nil {
+			return failedBatchItem(err)
+		}
+	case batchActionDelete:
+		if err := h.deleteOperation(ctx, userUUID, item.UUID); err != nil {
+			return failedBatchItem(err)
+		}
+	default:
+		return failedBatchItem(invalidField("action", "must be one of: create, update, delete"))
+	}
+	return BatchResult{Status: http.StatusNoContent, UUID: item.UUID}
+}
+
+func invalidField(field, message string) *apperror.AppError {
+	appErr := apperror.UnprocessableEntityError("validation failed")
+	appErr.WithFields(apperror.ErrorFields{field: message})
+	return appErr
+}
+
+func failedBatchItem(err error) BatchResult {
+	appErr := apperror.From(err)
+	return BatchResult{Status: appErr.HTTPStatus(), Error: appErr}
+}
+
+// runConcurrently calls fn for indexes from 0 to n-1, at most Batch.Concurrency calls at the same time
+func (h *operationHandler) runConcurrently(n int, fn func(i int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, h.Batch.Concurrency)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,124 @@
+package operations
+
+import (
+	"errors"
+	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/pkg/money"
+	"finance-manager-api-service/pkg/rest"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+
+	limitParam  = "limit"
+	offsetParam = "offset"
+)
+
+var allowedOperators = map[string][]string{
+	"category_uuid": {rest.OperatorNoOperatorUsed, rest.OperatorEqual, rest.OperatorIn},
+	"description":   {rest.OperatorNoOperatorUsed, rest.OperatorSubString},
+	"currency":      {rest.OperatorNoOperatorUsed, rest.OperatorEqual, rest.OperatorIn},
+	"money_sum": {rest.OperatorNoOperatorUsed, rest.OperatorEqual, rest.OperatorNotEqual, rest.OperatorLowerThan,
+		rest.OperatorLowerThanEq, rest.OperatorGreaterThan, rest.OperatorGreaterThanEq, rest.OperatorBetween},
+	"date_time": {rest.OperatorNoOperatorUsed, rest.OperatorEqual, rest.OperatorLowerThan, rest.OperatorLowerThanEq,
+		rest.OperatorGreaterThan, rest.OperatorGreaterThanEq, rest.OperatorBetween},
+	"sort_by":    {rest.OperatorNoOperatorUsed},
+	"sort_order": {rest.OperatorNoOperatorUsed},
+}
+
+var allowedSortValues = map[string][]string{
+	"sort_by":    {"money_sum", "date_time", "description"},
+	"sort_order": {"asc", "desc"},
+}
+
+// parsePagination reads limit and offset from query and removes them from it
+func parsePagination(query url.Values) (limit, offset int, err error) {
+	limit, offset = defaultLimit, 0
+
+	if raw := query.Get(limitParam); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxLimit {
+			return 0, 0, apperror.BadRequestError("limit must be an integer between 1 and " + strconv.Itoa(maxLimit))
+		}
+	}
+	if raw := query.Get(offsetParam); raw != "" {
+		offset, err = strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return 0, 0, apperror.BadRequestError("offset must be a non-negative integer")
+		}
+	}
+
+	query.Del(limitParam)
+	query.Del(offsetParam)
+	return limit, offset, nil
+}
+
+// parseListFilters converts query params into filter options accepted by the operation-service
+func parseListFilters(query url.Values) ([]rest.FilterOptions, error) {
+	filters, err := rest.ParseFilterOptions(query)
+	if err != nil {
+		var filterErr *rest.FilterError
+		if !errors.As(err, &filterErr) {
+			return nil, err
+		}
+		appErr := apperror.BadRequestError("invalid filter parameters")
+		appErr.WithFields(apperror.ErrorFields{filterErr.Field: filterErr.Message})
+		return nil, appErr
+	}
+	fields := make(apperror.ErrorFields)
+
+	for _, fo := range filters {
+		operators, ok := allowedOperators[fo.Field]
+		if !ok {
+			fields[fo.Field] = "unknown filter"
+			continue
+		}
+		if !slices.Contains(operators, fo.Operator) {
+			fields[fo.Field] = "unsupported operator: " + fo.Operator
+			continue
+		}
+		if fo.Operator == rest.OperatorBetween && len(fo.Values) != 2 {
+			fields[fo.Field] = "between requires exactly two values"
+			continue
+		}
+		if msg := validateValues(fo); msg != "" {
+			fields[fo.Field] = msg
+		}
+	}
+
+	if len(fields) > 0 {
+		appErr := apperror.BadRequestError("invalid filter parameters")
+		appErr.WithFields(fields)
+		return nil, appErr
+	}
+	return filters, nil
+}
+
+func validateValues(fo rest.FilterOptions) string {
+	for _, value := range fo.Values {
+		switch fo.Field {
+		case "money_sum":
+			if _, err := money.Parse(value); err != nil {
+				return "must be a number"
+			}
+		case "currency":
+			if !money.IsCurrencyCode(value) {
+				return "must be ISO 4217 currency code"
+			}
+		case "date_time":
+			if _, err := time.Parse(time.DateOnly, value); err != nil {
+				return "must be a date in format yyyy-mm-dd"
+			}
+		case "sort_by", "sort_order":
+			if !slices.Contains(allowedSortValues[fo.Field], value) {
+				return "unsupported value: " + value
+			}
+		}
+	}
+	return ""
+}
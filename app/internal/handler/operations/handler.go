@@ -1,37 +1,68 @@
 package operations
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/internal/client/operation_service/category"
 	"finance-manager-api-service/internal/client/operation_service/operation"
+	"finance-manager-api-service/internal/client/user_service"
 	h "finance-manager-api-service/internal/handler"
+	"finance-manager-api-service/internal/ownership"
 	"finance-manager-api-service/pkg/jwt"
 	"finance-manager-api-service/pkg/logging"
+	"finance-manager-api-service/pkg/rest"
 	"finance-manager-api-service/pkg/utils"
+	"finance-manager-api-service/pkg/validate"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 const (
 	operationsURL    = "/api/operations"
 	operationByIdURL = "/api/operations/:uuid"
+
+	expandCategory = "category"
 )
 
 type operationHandler struct {
 	Logger           *logging.Logger
 	OperationService operation.Service
+	CategoryService  category.Service
+	UserService      user_service.UserService
+	Ownership        ownership.Checker
+	Batch            BatchLimits
+	Import           ImportLimits
+	// used for users without base currency in profile
+	DefaultCurrency string
 }
 
-func NewOperationHandler(logger *logging.Logger, operationService operation.Service) h.Handler {
+func NewOperationHandler(logger *logging.Logger, operationService operation.Service, categoryService category.Service,
+	userService user_service.UserService, ownershipChecker ownership.Checker, batchLimits BatchLimits,
+	importLimits ImportLimits, defaultCurrency string) h.Handler {
+	batchLimits.Concurrency = max(batchLimits.Concurrency, 1)
 	return &operationHandler{
 		Logger:           logger,
 		OperationService: operationService,
+		CategoryService:  categoryService,
+		UserService:      userService,
+		Ownership:        ownershipChecker,
+		Batch:            batchLimits,
+		Import:           importLimits,
+		DefaultCurrency:  defaultCurrency,
 	}
 }
 
 func (h *operationHandler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodPost, operationsURL, jwt.Middleware(apperror.Middleware(h.CreateOperation)))
+	router.HandlerFunc(http.MethodGet, operationsURL, jwt.Middleware(apperror.Middleware(h.GetOperations)))
+	router.HandlerFunc(http.MethodPost, batchURL, jwt.Middleware(apperror.Middleware(h.ExecuteBatch)))
+	router.HandlerFunc(http.MethodPost, importURL, jwt.Middleware(apperror.Middleware(h.ImportOperations)))
 	router.HandlerFunc(http.MethodGet, operationByIdURL, jwt.Middleware(apperror.Middleware(h.GetOperationByUUID)))
 	router.HandlerFunc(http.MethodPatch, operationByIdURL, jwt.Middleware(apperror.Middleware(h.PartiallyUpdateOperation)))
 	router.HandlerFunc(http.MethodDelete, operationByIdURL, jwt.Middleware(apperror.Middleware(h.DeleteOperation)))
@@ -44,9 +75,12 @@ func (h *operationHandler) Register(router *httprouter.Router) {
 // @Tags 		Operation
 // @Accept		json
 // @Param 		input	body 	 operation.CreateOperationDTO	true	"Operation's data"
+// @Param 		Idempotency-Key	header	string	false	"Repeated request with the same key does not create operation twice"
 // @Success 	201
 // @Failure 	401 		   						"Unauthorized"
-// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	400 	{object} apperror.AppError "Invalid JSON body"
+// @Failure 	422 	{object} apperror.AppError "Validation error"
+// @Failure 	404 	{object} apperror.AppError "Category not found"
 // @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
 // @Failure 	500 	{object} apperror.AppError "Internal server error"
 // @Router /operations [post]
@@ -54,12 +88,19 @@ func (h *operationHandler) CreateOperation(w http.ResponseWriter, r *http.Reques
 	w.Header().Set("Content-Type", "application/json")
 	defer utils.CloseBody(h.Logger, r.Body)
 
+	if r.Context().Value("user_uuid") == nil {
+		h.Logger.Error("no user_uuid in context")
+		return apperror.UnauthorizedError("")
+	}
+	userUUID := r.Context().Value("user_uuid").(string)
+
 	var createdOperation operation.CreateOperationDTO
-	if err := json.NewDecoder(r.Body).Decode(&createdOperation); err != nil {
-		return apperror.BadRequestError("invalid JSON body")
+	if err := decodeOperationDTO(r.Body, &createdOperation); err != nil {
+		return err
 	}
 
-	operationUUID, err := h.OperationService.Create(r.Context(), createdOperation)
+	ctx := rest.WithIdempotencyKey(r.Context(), rest.IdempotencyKey(r))
+	operationUUID, err := h.createOperation(ctx, userUUID, createdOperation)
 	if err != nil {
 		return err
 	}
@@ -69,6 +110,91 @@ func (h *operationHandler) CreateOperation(w http.ResponseWriter, r *http.Reques
 	return nil
 }
 
+// GetOperations
+// @Summary 	Get user's operations
+// @Description Get paginated list of user's operations with support for filtering and sorting
+// @Security	JWTAuth
+// @Tags 		Operation
+// @Produce 	json
+// @Param 		category_uuid query 	string false "Category uuid (supports operators: eq, in)"
+// @Param 		description   query 	string false "Description (supports operators: substr)"
+// @Param 		money_sum 	  query 	string false "Money sum (supports operators: eq, neq, lt, lte, gt, gte, between)"
+// @Param 		currency 	  query 	string false "Currency of operation (supports operators: eq, in)"
+// @Param 		date_time     query 	string false "Date of operation (supports operators: eq, lt, lte, gt, gte, between; format: yyyy-mm-dd)"
+// @Param 		sort_by 	  query 	string false "Field to sort by (money_sum, date_time, description)"
+// @Param 		sort_order 	  query 	string false "Sort order (asc, desc)"
+// @Param 		limit 		  query 	int    false "Page size (default 50, max 500)"
+// @Param 		offset 		  query 	int    false "Number of operations to skip"
+// @Param 		expand 		  query 	string false "Embed related resources into operations" Enums(category)
+// @Success 	200 		  {object} operation.OperationsPage "Operations"
+// @Failure 	401 		   									"Unauthorized"
+// @Failure 	400 		  {object} apperror.AppError 		"Validation error in filter, pagination or expand parameters"
+// @Failure 	418 		  {object} apperror.AppError 		"Something wrong with application logic"
+// @Failure 	500 		  {object} apperror.AppError 		"Internal server error"
+// @Router /operations [get]
+func (h *operationHandler) GetOperations(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Context().Value("user_uuid") == nil {
+		h.Logger.Error("no user_uuid in context")
+		return apperror.UnauthorizedError("")
+	}
+	userUUID := r.Context().Value("user_uuid").(string)
+
+	query := r.URL.Query()
+	expand, err := rest.ParseExpand(query, expandCategory)
+	if err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+	limit, offset, err := parsePagination(query)
+	if err != nil {
+		return err
+	}
+	filters, err := parseListFilters(query)
+	if err != nil {
+		return err
+	}
+
+	// one extra operation is requested to find out whether there is a next page
+	filters = append(filters,
+		rest.FilterOptions{Field: "user_uuid", Values: []string{userUUID}},
+		rest.FilterOptions{Field: limitParam, Values: []string{strconv.Itoa(limit + 1)}},
+		rest.FilterOptions{Field: offsetParam, Values: []string{strconv.Itoa(offset)}},
+	)
+
+	ops, err := h.OperationService.GetByFilters(r.Context(), filters)
+	if err != nil {
+		return err
+	}
+
+	page := operation.OperationsPage{
+		Operations: ops,
+		Limit:      limit,
+		Offset:     offset,
+	}
+	if len(ops) > limit {
+		page.Operations = ops[:limit]
+		page.HasMore = true
+	}
+	if page.Operations == nil {
+		page.Operations = []operation.Operation{}
+	}
+	if expand[expandCategory] {
+		if err = h.expandCategories(r.Context(), userUUID, page.Operations); err != nil {
+			return err
+		}
+	}
+
+	pageBytes, err := json.Marshal(page)
+	if err != nil {
+		return fmt.Errorf("failed to marshal operations: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(pageBytes)
+	return nil
+}
+
 // GetOperationByUUID
 // @Summary 	Get operation by uuid
 // @Description Get operation by uuid
@@ -76,8 +202,10 @@ func (h *operationHandler) CreateOperation(w http.ResponseWriter, r *http.Reques
 // @Tags 		Operation
 // @Produce 	json
 // @Param 		uuid 	path 	 string 	true   		"Operation's uuid"
+// @Param 		expand 	query 	 string 	false 		"Embed related resources into operation" Enums(category)
 // @Success 	200		{object} operation.Operation  	"Operation"
 // @Failure 	401 		   						"Unauthorized"
+// @Failure 	400 	{object} apperror.AppError "Unsupported expand parameter"
 // @Failure 	404 	{object} apperror.AppError "Operation not found"
 // @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
 // @Failure 	500 	{object} apperror.AppError "Internal server error"
@@ -85,16 +213,48 @@ func (h *operationHandler) CreateOperation(w http.ResponseWriter, r *http.Reques
 func (h *operationHandler) GetOperationByUUID(w http.ResponseWriter, r *http.Request) error {
 	w.Header().Set("Content-Type", "application/json")
 
+	if r.Context().Value("user_uuid") == nil {
+		h.Logger.Error("no user_uuid in context")
+		return apperror.UnauthorizedError("")
+	}
+	userUUID := r.Context().Value("user_uuid").(string)
+
 	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
 	operationUUID := params.ByName("uuid")
 
-	op, err := h.OperationService.GetByUUID(r.Context(), operationUUID)
+	expand, err := rest.ParseExpand(r.URL.Query(), expandCategory)
+	if err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	if err = h.Ownership.CheckOperation(r.Context(), userUUID, operationUUID); err != nil {
+		return err
+	}
+
+	opBytes, err := h.OperationService.GetByUUID(r.Context(), operationUUID)
 	if err != nil {
 		return err
 	}
 
+	// operation is decoded even without expand, so its time is in UTC as in the list of operations
+	var op operation.Operation
+	if err = json.Unmarshal(opBytes, &op); err != nil {
+		return fmt.Errorf("failed to unmarshal operation: %w", err)
+	}
+	op.DateTime = op.DateTime.UTC()
+	if expand[expandCategory] {
+		ops := []operation.Operation{op}
+		if err = h.expandCategories(r.Context(), userUUID, ops); err != nil {
+			return err
+		}
+		op = ops[0]
+	}
+	if opBytes, err = json.Marshal(op); err != nil {
+		return fmt.Errorf("failed to marshal operation: %w", err)
+	}
+
 	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write(op)
+	_, _ = w.Write(opBytes)
 	return nil
 }
 
@@ -108,23 +268,31 @@ func (h *operationHandler) GetOperationByUUID(w http.ResponseWriter, r *http.Req
 // @Param 		input 		body 	 operation.UpdateOperationDTO 	true  "Operation's data"
 // @Success 	204
 // @Failure 	401 		   						"Unauthorized"
-// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	400 	{object} apperror.AppError "Invalid JSON body"
+// @Failure 	422 	{object} apperror.AppError "Validation error"
+// @Failure 	404 	{object} apperror.AppError "Operation or category not found"
 // @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
 // @Failure 	500 	{object} apperror.AppError "Internal server error"
 // @Router /operations/:uuid [patch]
 func (h *operationHandler) PartiallyUpdateOperation(w http.ResponseWriter, r *http.Request) error {
 	w.Header().Set("Content-Type", "application/json")
 
+	if r.Context().Value("user_uuid") == nil {
+		h.Logger.Error("no user_uuid in context")
+		return apperror.UnauthorizedError("")
+	}
+	userUUID := r.Context().Value("user_uuid").(string)
+
 	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
 	operationUUID := params.ByName("uuid")
 
 	var updatedOperation operation.UpdateOperationDTO
 	defer utils.CloseBody(h.Logger, r.Body)
-	if err := json.NewDecoder(r.Body).Decode(&updatedOperation); err != nil {
-		return apperror.BadRequestError("invalid JSON body")
+	if err := decodeOperationDTO(r.Body, &updatedOperation); err != nil {
+		return err
 	}
 
-	if err := h.OperationService.Update(r.Context(), operationUUID, updatedOperation); err != nil {
+	if err := h.updateOperation(r.Context(), userUUID, operationUUID, updatedOperation); err != nil {
 		return err
 	}
 
@@ -147,13 +315,76 @@ func (h *operationHandler) PartiallyUpdateOperation(w http.ResponseWriter, r *ht
 func (h *operationHandler) DeleteOperation(w http.ResponseWriter, r *http.Request) error {
 	w.Header().Set("Content-Type", "application/json")
 
+	if r.Context().Value("user_uuid") == nil {
+		h.Logger.Error("no user_uuid in context")
+		return apperror.UnauthorizedError("")
+	}
+	userUUID := r.Context().Value("user_uuid").(string)
+
 	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
 	operationUUID := params.ByName("uuid")
 
-	if err := h.OperationService.Delete(r.Context(), operationUUID); err != nil {
+	if err := h.deleteOperation(r.Context(), userUUID, operationUUID); err != nil {
 		return err
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 	return nil
 }
+
+// expandCategories embeds name and type of category into every operation, categories of the user
+// are requested once for all operations
+func (h *operationHandler) expandCategories(ctx context.Context, userUUID string, ops []operation.Operation) error {
+	resolver := category.NewResolver(h.CategoryService, userUUID)
+	for i := range ops {
+		info, err := resolver.Info(ctx, ops[i].CategoryUUID)
+		if err != nil {
+			return err
+		}
+		ops[i].Category = info
+	}
+	return nil
+}
+
+func (h *operationHandler) createOperation(ctx context.Context, userUUID string,
+	dto operation.CreateOperationDTO) (string, error) {
+	if err := h.Ownership.CheckCategory(ctx, userUUID, dto.CategoryUUID); err != nil {
+		return "", err
+	}
+	return h.OperationService.Create(ctx, dto)
+}
+
+func (h *operationHandler) updateOperation(ctx context.Context, userUUID, operationUUID string,
+	dto operation.UpdateOperationDTO) error {
+	if err := h.Ownership.CheckOperation(ctx, userUUID, operationUUID); err != nil {
+		return err
+	}
+	if dto.CategoryUUID != "" {
+		if err := h.Ownership.CheckCategory(ctx, userUUID, dto.CategoryUUID); err != nil {
+			return err
+		}
+	}
+	return h.OperationService.Update(ctx, operationUUID, dto)
+}
+
+func (h *operationHandler) deleteOperation(ctx context.Context, userUUID, operationUUID string) error {
+	if err := h.Ownership.CheckOperation(ctx, userUUID, operationUUID); err != nil {
+		return err
+	}
+	return h.OperationService.Delete(ctx, operationUUID)
+}
+
+// decodeOperationDTO decodes and validates operation from request body, date in wrong format is reported
+// as invalid field rather than as invalid JSON
+func decodeOperationDTO(body io.Reader, dto any) error {
+	if err := json.NewDecoder(body).Decode(dto); err != nil {
+		var timeErr *time.ParseError
+		if errors.As(err, &timeErr) {
+			appErr := apperror.UnprocessableEntityError("validation failed")
+			appErr.WithFields(apperror.ErrorFields{"date_time": "must be RFC 3339 date and time with time zone"})
+			return appErr
+		}
+		return apperror.BadRequestError("invalid JSON body")
+	}
+	return validate.Struct(dto)
+}
@@ -0,0 +1,479 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/internal/client/operation_service/category"
+	"finance-manager-api-service/internal/client/operation_service/operation"
+	"finance-manager-api-service/pkg/money"
+	"finance-manager-api-service/pkg/rest"
+	"finance-manager-api-service/pkg/statement"
+	"finance-manager-api-service/pkg/validate"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	importURL = "/api/operations/import"
+
+	importStatusNew       = "new"
+	importStatusDuplicate = "duplicate"
+	importStatusInvalid   = "invalid"
+	importStatusCreated   = "created"
+	importStatusFailed    = "failed"
+
+	directionIncome  = "income"
+	directionExpense = "expense"
+
+	// page size used to load existing operations for deduplication
+	existingOperationsPage = 500
+)
+
+// ImportLimits bounds size of uploaded statement
+type ImportLimits struct {
+	MaxFileSize     int64
+	MaxTransactions int
+}
+
+// ImportOptions is JSON sent in "options" form field together with statement file
+type ImportOptions struct {
+	// csv or ofx, detected by file extension when it is empty
+	Format string               `json:"format" validate:"omitempty,oneof=csv ofx"`
+	CSV    statement.CSVMapping `json:"csv"`
+	// currency of transactions when statement does not specify it, empty means base currency of the user
+	Currency string       `json:"currency" validate:"omitempty,currency"`
+	Rules    []ImportRule `json:"rules"`
+	// categories of transactions that matched no rule
+	DefaultIncomeCategoryUUID  string `json:"default_income_category_uuid" validate:"omitempty,uuid"`
+	DefaultExpenseCategoryUUID string `json:"default_expense_category_uuid" validate:"omitempty,uuid"`
+}
+
+// ImportRule assigns category to transactions which description contains Contains (case-insensitive).
+// Rules are checked in order, the first matching rule wins. Contains is required: catch-all rules are
+// expressed with default categories of ImportOptions.
+type ImportRule struct {
+	Contains string `json:"contains" validate:"required"`
+	// income matches positive amounts, expense matches negative ones, empty matches both
+	Direction    string `json:"direction" validate:"omitempty,oneof=income expense"`
+	CategoryUUID string `json:"category_uuid" validate:"required,uuid"`
+}
+
+type ImportItem struct {
+	Line      int                           `json:"line"`
+	Status    string                        `json:"status" enums:"new,duplicate,invalid,created,failed"`
+	Operation *operation.CreateOperationDTO `json:"operation,omitempty"`
+	UUID      string                        `json:"uuid,omitempty"`
+	Error     *apperror.AppError            `json:"error,omitempty"`
+	// income or expense by sign of the transaction amount
+	direction string
+}
+
+// ImportResult lists every transaction of statement with its status. In dry run transactions
+// that would be created have status "new", otherwise "created" or "failed".
+type ImportResult struct {
+	DryRun     bool         `json:"dry_run"`
+	Total      int          `json:"total"`
+	New        int          `json:"new"`
+	Created    int          `json:"created"`
+	Duplicates int          `json:"duplicates"`
+	Invalid    int          `json:"invalid"`
+	Failed     int          `json:"failed"`
+	Items      []ImportItem `json:"items"`
+}
+
+// ImportOperations
+// @Summary 	Import operations from bank statement
+// @Description Parses CSV or OFX/QFX statement, assigns categories by rules and skips transactions
+// @Description that already exist with the same date, direction, amount, currency and description. Identical
+// @Description transactions of the statement are skipped only as many times as they already exist. Nothing is created
+// @Description unless dry_run=false is passed, so the preview can be checked first.
+// @Security	JWTAuth
+// @Tags 		Operation
+// @Accept		mpfd
+// @Produce 	json
+// @Param 		file 	formData file 	true  "CSV or OFX/QFX statement"
+// @Param 		options formData string false "ImportOptions as JSON"
+// @Param 		dry_run query 	 bool 	false "Only preview the import (default true)"
+// @Param 		Idempotency-Key header string false "Repeated import with the same key does not create operations twice"
+// @Success 	200 	{object} ImportResult "Imported operations"
+// @Failure 	401 		   					"Unauthorized"
+// @Failure 	400 	{object} apperror.AppError "Invalid form or options"
+// @Failure 	404 	{object} apperror.AppError "Category of rule is not found"
+// @Failure 	422 	{object} apperror.AppError "Statement can not be parsed or is too large"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router /operations/import [post]
+func (h *operationHandler) ImportOperations(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Context().Value("user_uuid") == nil {
+		h.Logger.Error("no user_uuid in context")
+		return apperror.UnauthorizedError("")
+	}
+	userUUID := r.Context().Value("user_uuid").(string)
+
+	dryRun := true
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return apperror.BadRequestError("dry_run must be true or false")
+		}
+		dryRun = parsed
+	}
+
+	transactions, options, err := h.readStatement(w, r)
+	if err != nil {
+		return err
+	}
+	if err = h.checkRuleCategories(r.Context(), userUUID, options); err != nil {
+		return err
+	}
+
+	result := ImportResult{
+		DryRun: dryRun,
+		Items:  make([]ImportItem, len(transactions)),
+	}
+	for i, tx := range transactions {
+		result.Items[i] = newImportItem(tx, options)
+	}
+	if err = h.markDuplicates(r.Context(), userUUID, result.Items); err != nil {
+		return err
+	}
+
+	if !dryRun {
+		h.Logger.Infof("import operations from statement of %d transactions", len(transactions))
+		h.runConcurrently(len(result.Items), func(i int) {
+			item := &result.Items[i]
+			if item.Status != importStatusNew {
+				return
+			}
+			ctx := rest.WithIdempotencyKey(r.Context(), rest.ItemIdempotencyKey(r, i))
+			operationUUID, err := h.createOperation(ctx, userUUID, *item.Operation)
+			if err != nil {
+				item.Status = importStatusFailed
+				item.Error = apperror.From(err)
+				return
+			}
+			item.Status = importStatusCreated
+			item.UUID = operationUUID
+		})
+	}
+
+	result.count()
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(resultBytes)
+	return nil
+}
+
+// readStatement parses uploaded file with options from multipart form
+func (h *operationHandler) readStatement(w http.ResponseWriter, r *http.Request) ([]statement.Transaction,
+	ImportOptions, error) {
+	var options ImportOptions
+
+	// options and form boundaries take much less than 1 MiB
+	r.Body = http.MaxBytesReader(w, r.Body, h.Import.MaxFileSize+1<<20)
+	if err := r.ParseMultipartForm(h.Import.MaxFileSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, options, invalidField("file", fmt.Sprintf("must not be larger than %d bytes",
+				h.Import.MaxFileSize))
+		}
+		return nil, options, apperror.BadRequestError("invalid multipart form")
+	}
+	defer func() {
+		_ = r.MultipartForm.RemoveAll()
+	}()
+
+	if raw := r.FormValue("options"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &options); err != nil {
+			return nil, options, apperror.BadRequestError("invalid JSON in options")
+		}
+	}
+	if err := validateImportOptions(options); err != nil {
+		return nil, options, err
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, options, invalidField("file", "is required")
+	}
+	defer file.Close()
+
+	format := options.Format
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(header.Filename)) {
+		case ".csv":
+			format = statement.FormatCSV
+		case ".ofx", ".qfx":
+			format = statement.FormatOFX
+		}
+	}
+
+	transactions, err := parseStatement(file, format, options.CSV)
+	if err != nil {
+		return nil, options, invalidField("file", err.Error())
+	}
+	if len(transactions) == 0 {
+		return nil, options, invalidField("file", "has no transactions")
+	}
+	if len(transactions) > h.Import.MaxTransactions {
+		return nil, options, invalidField("file", "must not have more than "+
+			strconv.Itoa(h.Import.MaxTransactions)+" transactions")
+	}
+	return transactions, options, nil
+}
+
+func parseStatement(file io.Reader, format string, mapping statement.CSVMapping) ([]statement.Transaction, error) {
+	switch format {
+	case statement.FormatCSV:
+		return statement.ParseCSV(file, mapping)
+	case statement.FormatOFX:
+		return statement.ParseOFX(file)
+	default:
+		return nil, statement.ErrUnsupportedFormat
+	}
+}
+
+func validateImportOptions(options ImportOptions) error {
+	err := validate.Struct(options)
+	var appErr *apperror.AppError
+	if err != nil && !errors.As(err, &appErr) {
+		return err
+	}
+
+	fields := apperror.ErrorFields{}
+	if appErr != nil {
+		fields = appErr.Fields
+	}
+	for i, rule := range options.Rules {
+		if err = validate.Struct(rule); err == nil {
+			continue
+		}
+		if !errors.As(err, &appErr) {
+			return err
+		}
+		for field, message := range appErr.Fields {
+			fields[fmt.Sprintf("rules[%d].%s", i, field)] = message
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	appErr = apperror.UnprocessableEntityError("validation failed")
+	appErr.WithFields(fields)
+	return appErr
+}
+
+// checkRuleCategories makes sure every category used by options belongs to the user
+func (h *operationHandler) checkRuleCategories(ctx context.Context, userUUID string, options ImportOptions) error {
+	categories := map[string]struct{}{}
+	for _, rule := range options.Rules {
+		categories[rule.CategoryUUID] = struct{}{}
+	}
+	for _, categoryUUID := range []string{options.DefaultIncomeCategoryUUID, options.DefaultExpenseCategoryUUID} {
+		if categoryUUID != "" {
+			categories[categoryUUID] = struct{}{}
+		}
+	}
+
+	for categoryUUID := range categories {
+		if err := h.Ownership.CheckCategory(ctx, userUUID, categoryUUID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newImportItem(tx statement.Transaction, options ImportOptions) ImportItem {
+	item := ImportItem{
+		Line:   tx.Line,
+		Status: importStatusInvalid,
+	}
+	if tx.Err != nil {
+		item.Error = invalidField("line", tx.Err.Error())
+		return item
+	}
+
+	direction := directionIncome
+	amount := tx.Amount
+	if amount.Sign() < 0 {
+		direction = directionExpense
+		amount = amount.Neg()
+	}
+	item.direction = direction
+
+	date := tx.Date.UTC()
+	dto := operation.CreateOperationDTO{
+		CategoryUUID: categoryFor(tx.Description, direction, options),
+		MoneySum:     amount,
+		Currency:     tx.Currency,
+		Description:  tx.Description,
+		DateTime:     &date,
+	}
+	if dto.Currency == "" {
+		dto.Currency = options.Currency
+	}
+	item.Operation = &dto
+
+	if dto.CategoryUUID == "" {
+		item.Error = invalidField("category_uuid", "no rule matched transaction")
+		return item
+	}
+	if err := validate.Struct(dto); err != nil {
+		item.Error = apperror.From(err)
+		return item
+	}
+
+	item.Status = importStatusNew
+	return item
+}
+
+func categoryFor(description, direction string, options ImportOptions) string {
+	description = strings.ToLower(description)
+	for _, rule := range options.Rules {
+		if rule.Direction != "" && rule.Direction != direction {
+			continue
+		}
+		if strings.Contains(description, strings.ToLower(rule.Contains)) {
+			return rule.CategoryUUID
+		}
+	}
+
+	if direction == directionExpense {
+		return options.DefaultExpenseCategoryUUID
+	}
+	return options.DefaultIncomeCategoryUUID
+}
+
+// markDuplicates marks new items that have the same date, direction, amount, currency and description as
+// existing operation of the user. Empty currency of either side is the base currency of the user. Every existing operation matches one item at most, so identical transactions
+// of the statement, like two coffees bought the same day, are new unless both already exist.
+func (h *operationHandler) markDuplicates(ctx context.Context, userUUID string, items []ImportItem) error {
+	var from, to time.Time
+	for _, item := range items {
+		if item.Status != importStatusNew {
+			continue
+		}
+		date := *item.Operation.DateTime
+		if from.IsZero() || date.Before(from) {
+			from = date
+		}
+		if date.After(to) {
+			to = date
+		}
+	}
+	if from.IsZero() {
+		return nil
+	}
+
+	existing, err := h.loadOperations(ctx, userUUID, from, to)
+	if err != nil {
+		return err
+	}
+	// direction of existing operation is type of its category
+	categories, err := category.NewResolver(h.CategoryService, userUUID).Categories(ctx)
+	if err != nil {
+		return err
+	}
+	baseCurrency, err := h.baseCurrency(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+	currencyOf := func(currency string) string {
+		if currency == "" {
+			return baseCurrency
+		}
+		return currency
+	}
+
+	unmatched := make(map[string]int, len(existing))
+	for _, op := range existing {
+		key := duplicateKey(op.DateTime, categories[op.CategoryUUID].Type, currencyOf(op.Currency), op.MoneySum,
+			op.Description)
+		unmatched[key]++
+	}
+
+	for i := range items {
+		if items[i].Status != importStatusNew {
+			continue
+		}
+		op := items[i].Operation
+		key := duplicateKey(*op.DateTime, items[i].direction, currencyOf(op.Currency), op.MoneySum, op.Description)
+		if unmatched[key] > 0 {
+			unmatched[key]--
+			items[i].Status = importStatusDuplicate
+		}
+	}
+	return nil
+}
+
+// loadOperations returns all operations of the user dated from one day to another, both inclusive
+func (h *operationHandler) loadOperations(ctx context.Context, userUUID string,
+	from, to time.Time) ([]operation.Operation, error) {
+	var operations []operation.Operation
+	to = to.AddDate(0, 0, 1)
+	for offset := 0; ; offset += existingOperationsPage {
+		page, err := h.OperationService.GetByFilters(ctx, []rest.FilterOptions{
+			{Field: "user_uuid", Values: []string{userUUID}},
+			{Field: "date_time", Operator: rest.OperatorBetween,
+				Values: []string{from.Format(time.DateOnly), to.Format(time.DateOnly)}},
+			{Field: limitParam, Values: []string{strconv.Itoa(existingOperationsPage)}},
+			{Field: offsetParam, Values: []string{strconv.Itoa(offset)}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, page...)
+		if len(page) < existingOperationsPage {
+			return operations, nil
+		}
+	}
+}
+
+// baseCurrency returns currency from profile of the user or the default one when profile has none
+func (h *operationHandler) baseCurrency(ctx context.Context, userUUID string) (string, error) {
+	user, err := h.UserService.GetByUUID(ctx, userUUID)
+	if err != nil {
+		return "", err
+	}
+	if user.BaseCurrency == "" {
+		return h.DefaultCurrency, nil
+	}
+	return user.BaseCurrency, nil
+}
+
+func duplicateKey(date time.Time, direction, currency string, amount money.Amount, description string) string {
+	return date.UTC().Format(time.DateOnly) + "|" + direction + "|" + strings.ToUpper(currency) + "|" +
+		amount.String() + "|" + strings.ToLower(strings.Join(strings.Fields(description), " "))
+}
+
+func (r *ImportResult) count() {
+	r.Total = len(r.Items)
+	for _, item := range r.Items {
+		switch item.Status {
+		case importStatusNew:
+			r.New++
+		case importStatusCreated:
+			r.Created++
+		case importStatusDuplicate:
+			r.Duplicates++
+		case importStatusInvalid:
+			r.Invalid++
+		case importStatusFailed:
+			r.Failed++
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package stats
+
+import (
+	"encoding/json"
+	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/internal/client/operation_service/category"
+	"finance-manager-api-service/internal/client/stats_service"
+	"finance-manager-api-service/pkg/export"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	formatQuery = "format"
+	formatJSON  = "json"
+)
+
+// reportFormat picks format of the report: format query parameter wins over Accept header,
+// JSON is returned when neither asks for something else
+func reportFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get(formatQuery); format != "" {
+		format = strings.ToLower(format)
+		if format != formatJSON && export.ContentType(format) == "" {
+			return "", apperror.BadRequestError("format must be one of: json, csv, xlsx, pdf")
+		}
+		return format, nil
+	}
+
+	accept := strings.Join(r.Header.Values("Accept"), ",")
+	if strings.TrimSpace(accept) == "" {
+		return formatJSON, nil
+	}
+
+	type mediaRange struct {
+		format string
+		q      float64
+	}
+	var ranges []mediaRange
+	for _, value := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(value)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil || q <= 0 {
+				continue
+			}
+		}
+
+		format := export.FormatOf(mediaType)
+		switch mediaType {
+		case "application/json", "application/*", "*/*":
+			format = formatJSON
+		}
+		if format != "" {
+			ranges = append(ranges, mediaRange{format: format, q: q})
+		}
+	}
+	if len(ranges) == 0 {
+		return "", apperror.NotAcceptableError("report is available as application/json, text/csv, " +
+			export.ContentType(export.FormatXLSX) + " or application/pdf")
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	return ranges[0].format, nil
+}
+
+func (h *handler) writeJSON(w http.ResponseWriter, report stats_service.Report) error {
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(reportBytes)
+	return nil
+}
+
+// writeExport streams report as file: operations with signed amounts, expenses are negative, and
+// groups of the report as the second table when they were requested. Once the status is sent
+// an error can not be rendered anymore, so failures of writing are only logged.
+func (h *handler) writeExport(w http.ResponseWriter, report stats_service.Report, format string,
+	categories map[string]category.Category) {
+	now := time.Now().UTC()
+	filename := "report-" + now.Format("20060102") + "." + format
+
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.WriteHeader(http.StatusOK)
+
+	operations := export.Table{
+		Title: "Financial report",
+		Notes: []string{
+			"Generated: " + now.Format("2006-01-02 15:04") + " UTC",
+			"Income: " + report.Summary.Income.String() + " " + report.Currency,
+			"Expense: " + report.Summary.Expense.String() + " " + report.Currency,
+			"Net: " + report.Summary.Net.String() + " " + report.Currency,
+		},
+		Columns: []export.Column{
+			{Title: "Date", Width: 16},
+			{Title: "Description", Width: 30},
+			{Title: "Category", Width: 24},
+			{Title: "Type", Width: 8},
+			{Title: "Amount", Width: 14},
+			{Title: "Currency", Width: 8},
+			{Title: "Amount in " + report.Currency, Width: 14},
+		},
+	}
+	for _, total := range report.Totals {
+		if total.Currency != report.Currency {
+			operations.Notes = append(operations.Notes, "In "+total.Currency+": "+total.MoneySum.String()+
+				" ("+total.ConvertedMoneySum.String()+" "+report.Currency+")")
+		}
+	}
+	tables := []export.Table{operations}
+	if len(report.Groups) > 0 {
+		tables = append(tables, export.Table{
+			Title: "Groups",
+			Notes: []string{"Sums in " + report.Currency},
+			Columns: []export.Column{
+				{Title: "Period", Width: 12},
+				{Title: "Category", Width: 24},
+				{Title: "Type", Width: 8},
+				{Title: "Operations", Width: 10},
+				{Title: "Income", Width: 14},
+				{Title: "Expense", Width: 14},
+				{Title: "Net", Width: 14},
+			},
+		})
+	}
+
+	writer, err := export.NewWriter(format, w, tables...)
+	if err != nil {
+		h.Logger.Errorf("failed to start %s export: %v", format, err)
+		return
+	}
+	for _, op := range report.Operations {
+		currency := op.Currency
+		if currency == "" {
+			currency = report.Currency
+		}
+		ctg := categories[op.CategoryUUID]
+		categoryName := ctg.Name
+		if categoryName == "" {
+			categoryName = op.CategoryUUID
+		}
+		err = writer.WriteRow(
+			export.Text(op.DateTime.Format("2006-01-02 15:04")),
+			export.Text(op.Description),
+			export.Text(categoryName),
+			export.Text(ctg.Type),
+			export.Number(stats_service.Signed(op.MoneySum, ctg.Type).String()),
+			export.Text(currency),
+			export.Number(stats_service.Signed(op.ConvertedMoneySum, ctg.Type).String()),
+		)
+		if err != nil {
+			h.Logger.Errorf("failed to write %s export: %v", format, err)
+			return
+		}
+	}
+	if len(report.Groups) > 0 {
+		if err = writeGroups(writer, report.Groups); err != nil {
+			h.Logger.Errorf("failed to write groups to %s export: %v", format, err)
+			return
+		}
+	}
+	if err = writer.Close(); err != nil {
+		h.Logger.Errorf("failed to complete %s export: %v", format, err)
+	}
+}
+
+// writeGroups writes groups as the next table, fields of grouping that was not requested are empty
+func writeGroups(writer export.Writer, groups []stats_service.Group) error {
+	if err := writer.NextTable(); err != nil {
+		return err
+	}
+	for _, group := range groups {
+		var period string
+		if group.PeriodStart != nil {
+			period = group.PeriodStart.Format(time.DateOnly)
+		}
+		categoryName := group.CategoryName
+		if categoryName == "" {
+			categoryName = group.CategoryUUID
+		}
+		err := writer.WriteRow(
+			export.Text(period),
+			export.Text(categoryName),
+			export.Text(group.CategoryType),
+			export.Number(strconv.Itoa(group.Count)),
+			export.Number(group.Income.String()),
+			export.Number(group.Expense.String()),
+			export.Number(group.Net.String()),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,30 +1,50 @@
 package stats
 
 import (
+	"errors"
 	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/internal/client/operation_service/category"
 	"finance-manager-api-service/internal/client/stats_service"
+	"finance-manager-api-service/internal/client/user_service"
 	h "finance-manager-api-service/internal/handler"
+	"finance-manager-api-service/pkg/exchange"
 	"finance-manager-api-service/pkg/jwt"
 	"finance-manager-api-service/pkg/logging"
 	"finance-manager-api-service/pkg/rest"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
 	"strings"
+	"time"
 )
 
 const (
 	statsURL = "/api/stats"
+
+	groupByQuery  = "group_by"
+	intervalQuery = "interval"
+
+	expandCategory = "category"
 )
 
 type handler struct {
-	Logger  *logging.Logger
-	Service stats_service.Service
+	Logger          *logging.Logger
+	Service         stats_service.Service
+	UserService     user_service.UserService
+	CategoryService category.Service
+	Rates           exchange.RateProvider
+	// used for users without base currency in profile
+	DefaultCurrency string
 }
 
-func NewHandler(logger *logging.Logger, service stats_service.Service) h.Handler {
+func NewHandler(logger *logging.Logger, service stats_service.Service, userService user_service.UserService,
+	categoryService category.Service, rates exchange.RateProvider, defaultCurrency string) h.Handler {
 	return &handler{
-		Logger:  logger,
-		Service: service,
+		Logger:          logger,
+		Service:         service,
+		UserService:     userService,
+		CategoryService: categoryService,
+		Rates:           rates,
+		DefaultCurrency: defaultCurrency,
 	}
 }
 
@@ -32,24 +52,44 @@ func (h *handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodGet, statsURL, jwt.Middleware(apperror.Middleware(h.GetReport)))
 }
 
-// GetReport
+// GetReport holds the whole report of the upstream in memory: sums are converted to base currency
+// and aggregated over all operations before the first row is written. Rows of exported files are
+// encoded as they are written, and the write deadline of the server is cleared for exports, so
+// large files are not cut off by WriteTimeout.
 // @Summary 	Get report about user's financial operations
 // @Description Retrieves a list of operations with support for filtering and sorting.
+// @Description The report is downloaded as CSV, XLSX or PDF file when it is requested by format
+// @Description parameter or Accept header, format parameter wins over the header.
+// @Description Summary with income, expense and net balance is always computed, groups are returned
+// @Description when group_by or interval is passed. All aggregated sums are in base currency of the user.
+// @Description The whole report is built in memory before it is written, narrow date_time for long periods.
+// @Description Exported files have category names, types and signed amounts (expenses are negative),
+// @Description groups follow the operations as the second table (sheet in XLSX).
 // @Security	JWTAuth
 // @Tags 		Stats
 // @Produce 	json
+// @Produce 	text/csv
+// @Produce 	application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce 	application/pdf
+// @Param 		format 		  query    string false  "Format of the report" Enums(json, csv, xlsx, pdf)
+// @Param 		group_by 	  query    string false  "Group operations by category or category type" Enums(category, type)
+// @Param 		interval 	  query    string false  "Group operations by period" Enums(day, week, month, year)
+// @Param 		expand 		  query    string false  "Embed related resources into operations" Enums(category)
 // @Param 		user_uuid 	  path 	   string false  "User UUID"
 // @Param 		category_name path 	   string false  "Category name (supports operators: substr)"
 // @Param 		type	 	  path 	   string false  "Category type"
 // @Param 		category_id   path 	   string false  "Category ID"
 // @Param 		description   path 	   string false  "Description (supports operators: substr)"
 // @Param 		money_sum 	  path 	   string false  "Money sum (supports operators: eq, neq, lt, lte, gt, gte, between)"
+// @Param 		currency 	  path 	   string false  "Currency of operations (supports operators: eq, in)"
 // @Param 		date_time     path 	   string false  "Date and time of operation (supports operators: eq, between; format: yyyy-mm-dd)"
 // @Param 		sort_by 	  path 	   string false  "Field to sort by (money_sum, date_time, description)"
 // @Param 		sort_order 	  path 	   string false  "Sort order (asc, desc)"
 // @Success 	200 		  {object} stats_service.Report "Report"
 // @Failure 	401 		   								"Unauthorized"
-// @Failure 	400 		  {object} apperror.AppError 	"Validation error in filter or sort parameters"
+// @Failure 	400 		  {object} apperror.AppError 	"Validation error in filter, sort, format, grouping or expand parameters"
+// @Failure 	406 		  {object} apperror.AppError 	"None of the accepted media types is supported"
+// @Failure 	422 		  {object} apperror.AppError 	"No exchange rate to base currency of the user"
 // @Failure 	418 		  {object} apperror.AppError 	"Something wrong with application logic"
 // @Failure 	500 		  {object} apperror.AppError 	"Internal server error"
 // @Router /stats [get]
@@ -62,31 +102,35 @@ func (h *handler) GetReport(w http.ResponseWriter, r *http.Request) error {
 	}
 	userUUID := r.Context().Value("user_uuid").(string)
 
-	params := r.URL.Query()
-
-	var filters []rest.FilterOptions
-	for key, values := range params {
-		for _, value := range values {
-			var operator string
-			var vals []string
-
-			if strings.Contains(value, ":") {
-				parts := strings.SplitN(value, ":", 2)
-				operator = parts[0]
-				vals = strings.Split(parts[1], ",")
-			} else {
-				operator = ""
-				vals = []string{value}
-			}
-
-			filters = append(filters, rest.FilterOptions{
-				Field:    key,
-				Operator: operator,
-				Values:   vals,
-			})
+	format, err := reportFormat(r)
+	if err != nil {
+		return err
+	}
+	if format != formatJSON {
+		if err = http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			h.Logger.Warnf("failed to clear write deadline of %s export: %v", format, err)
 		}
 	}
 
+	query := r.URL.Query()
+	expand, err := rest.ParseExpand(query, expandCategory)
+	if err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+	groupBy, interval := strings.ToLower(query.Get(groupByQuery)), strings.ToLower(query.Get(intervalQuery))
+	if !stats_service.IsGroupBy(groupBy) {
+		return apperror.BadRequestError("group_by must be one of: category, type")
+	}
+	if !stats_service.IsInterval(interval) {
+		return apperror.BadRequestError("interval must be one of: day, week, month, year")
+	}
+	for _, param := range []string{formatQuery, groupByQuery, intervalQuery} {
+		query.Del(param)
+	}
+	filters, err := rest.ParseFilterOptions(query)
+	if err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
 	filters = append(filters, rest.FilterOptions{
 		Field:    "user_uuid",
 		Operator: "",
@@ -98,7 +142,40 @@ func (h *handler) GetReport(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write(report)
+	user, err := h.UserService.GetByUUID(r.Context(), userUUID)
+	if err != nil {
+		return err
+	}
+	currency := user.BaseCurrency
+	if currency == "" {
+		currency = h.DefaultCurrency
+	}
+	if err = report.Convert(r.Context(), h.Rates, currency); err != nil {
+		if errors.Is(err, exchange.ErrRateNotFound) {
+			return apperror.UnprocessableEntityError(err.Error())
+		}
+		return err
+	}
+
+	resolver := category.NewResolver(h.CategoryService, userUUID)
+	categories, err := resolver.Categories(r.Context())
+	if err != nil {
+		return err
+	}
+	if err = report.Aggregate(groupBy, interval, categories); err != nil {
+		return err
+	}
+	if expand[expandCategory] {
+		for i, op := range report.Operations {
+			if report.Operations[i].Category, err = resolver.Info(r.Context(), op.CategoryUUID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if format == formatJSON {
+		return h.writeJSON(w, report)
+	}
+	h.writeExport(w, report, format, categories)
 	return nil
 }
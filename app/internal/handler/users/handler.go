@@ -8,6 +8,7 @@ import (
 	"finance-manager-api-service/pkg/jwt"
 	"finance-manager-api-service/pkg/logging"
 	"finance-manager-api-service/pkg/utils"
+	"finance-manager-api-service/pkg/validate"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
 )
@@ -19,12 +20,14 @@ const (
 type userHandler struct {
 	Logger      *logging.Logger
 	UserService user_service.UserService
+	JWTHelper   jwt.Helper
 }
 
-func NewUserHandler(logger *logging.Logger, userService user_service.UserService) h.Handler {
+func NewUserHandler(logger *logging.Logger, userService user_service.UserService, jwtHelper jwt.Helper) h.Handler {
 	return &userHandler{
 		Logger:      logger,
 		UserService: userService,
+		JWTHelper:   jwtHelper,
 	}
 }
 
@@ -41,7 +44,8 @@ func (h *userHandler) Register(router *httprouter.Router) {
 // @Accept		json
 // @Param 		input 	body 	user_service.UpdateUserDTO  true  "User's data"
 // @Success 	204
-// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	400 	{object} apperror.AppError "Invalid JSON body"
+// @Failure 	422 	{object} apperror.AppError "Validation error, base_currency is rejected when user-service is connected with gRPC"
 // @Failure 	401 		   					   "Unauthorized"
 // @Failure 	404 	{object} apperror.AppError "User is not found"
 // @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
@@ -63,11 +67,22 @@ func (h *userHandler) PartiallyUpdateUser(w http.ResponseWriter, r *http.Request
 	}
 
 	updatedUser.UUID = userUUID
+	if err := validate.Struct(updatedUser); err != nil {
+		return err
+	}
+
 	err := h.UserService.Update(r.Context(), updatedUser)
 	if err != nil {
 		return err
 	}
 
+	if updatedUser.NewPassword != nil {
+		h.Logger.Info("password changed, revoke user's sessions")
+		if err = h.JWTHelper.RevokeUserSessions(userUUID); err != nil {
+			return err
+		}
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 	return nil
 }
@@ -98,6 +113,10 @@ func (h *userHandler) DeleteUser(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	if err = h.JWTHelper.RevokeUserSessions(userUUID); err != nil {
+		return err
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 	return nil
 }
@@ -0,0 +1,86 @@
+package ownership
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/internal/client/operation_service/category"
+	"finance-manager-api-service/internal/client/operation_service/operation"
+	"finance-manager-api-service/pkg/logging"
+	"fmt"
+	"net/http"
+)
+
+// Checker verifies that resources stored in the operation-service belong to the user
+// that made the request. Resources owned by somebody else are reported as not found,
+// so their existence is not disclosed.
+type Checker interface {
+	CheckCategory(ctx context.Context, userUUID, categoryUUID string) error
+	CheckOperation(ctx context.Context, userUUID, operationUUID string) error
+}
+
+type checker struct {
+	Logger           *logging.Logger
+	CategoryService  category.Service
+	OperationService operation.Service
+}
+
+func NewChecker(logger *logging.Logger, categoryService category.Service, operationService operation.Service) Checker {
+	return &checker{
+		Logger:           logger,
+		CategoryService:  categoryService,
+		OperationService: operationService,
+	}
+}
+
+func (c *checker) CheckCategory(ctx context.Context, userUUID, categoryUUID string) error {
+	c.Logger.Debug("check category ownership")
+
+	categoryBytes, err := c.CategoryService.GetByUUID(ctx, categoryUUID)
+	if err != nil {
+		return notFoundOnAPIError(err)
+	}
+
+	var ctg category.Category
+	if err = json.Unmarshal(categoryBytes, &ctg); err != nil {
+		return fmt.Errorf("failed to unmarshal category: %w", err)
+	}
+
+	if ctg.UserUUID != userUUID {
+		c.Logger.Warnf("user %s tried to access category %s of another user", userUUID, categoryUUID)
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+func (c *checker) CheckOperation(ctx context.Context, userUUID, operationUUID string) error {
+	c.Logger.Debug("check operation ownership")
+
+	operationBytes, err := c.OperationService.GetByUUID(ctx, operationUUID)
+	if err != nil {
+		return notFoundOnAPIError(err)
+	}
+
+	var op operation.Operation
+	if err = json.Unmarshal(operationBytes, &op); err != nil {
+		return fmt.Errorf("failed to unmarshal operation: %w", err)
+	}
+
+	if err = c.CheckCategory(ctx, userUUID, op.CategoryUUID); err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			c.Logger.Warnf("user %s tried to access operation %s of another user", userUUID, operationUUID)
+		}
+		return err
+	}
+	return nil
+}
+
+// notFoundOnAPIError hides downstream rejections behind ErrNotFound, upstream failures are returned as is
+func notFoundOnAPIError(err error) error {
+	var appErr *apperror.AppError
+	if errors.As(err, &appErr) && appErr.HTTPStatus() < http.StatusInternalServerError {
+		return apperror.ErrNotFound
+	}
+	return err
+}
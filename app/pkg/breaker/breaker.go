@@ -0,0 +1,192 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	// Closed breaker lets all calls through and counts consecutive failures
+	Closed State = iota
+	// Open breaker rejects calls until OpenTimeout passes
+	Open
+	// HalfOpen breaker lets HalfOpenRequests trial calls through, their success closes it
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+type Config struct {
+	// consecutive failures that open the breaker
+	FailureThreshold int
+	OpenTimeout      time.Duration
+	HalfOpenRequests int
+	// called under lock of the breaker, must not call the breaker
+	OnStateChange func(name string, from, to State)
+	// called under lock of the breaker for every rejected call, must not call the breaker
+	OnReject func(name string)
+}
+
+// Breaker stops calls to upstream service that keeps failing, so callers fail fast instead of
+// waiting for timeouts. Nil Breaker lets all calls through.
+type Breaker struct {
+	name string
+	cfg  Config
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	// results of calls started before the last state change are ignored
+	generation int
+	inFlight   int
+	successes  int
+}
+
+func New(name string, cfg Config) *Breaker {
+	cfg.HalfOpenRequests = max(cfg.HalfOpenRequests, 1)
+	return &Breaker{
+		name: name,
+		cfg:  cfg,
+	}
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Allow reserves a call. If the call is allowed, done must be called with its outcome.
+func (b *Breaker) Allow() (done func(failed bool), err error) {
+	if b == nil {
+		return func(bool) {}, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open {
+		if time.Since(b.openedAt) < b.cfg.OpenTimeout {
+			return nil, b.reject()
+		}
+		b.setState(HalfOpen)
+	}
+	if b.state == HalfOpen {
+		if b.inFlight >= b.cfg.HalfOpenRequests {
+			return nil, b.reject()
+		}
+		b.inFlight++
+	}
+
+	generation := b.generation
+	return func(failed bool) {
+		b.record(generation, failed)
+	}, nil
+}
+
+func (b *Breaker) reject() error {
+	if b.cfg.OnReject != nil {
+		b.cfg.OnReject(b.name)
+	}
+	return fmt.Errorf("%s: %w", b.name, ErrOpen)
+}
+
+func (b *Breaker) record(generation int, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case Closed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.setState(Open)
+		}
+	case HalfOpen:
+		b.inFlight--
+		if failed {
+			b.setState(Open)
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenRequests {
+			b.setState(Closed)
+		}
+	}
+}
+
+func (b *Breaker) setState(state State) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.failures, b.inFlight, b.successes = 0, 0, 0
+	if state == Open {
+		b.openedAt = time.Now()
+	}
+	if b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(b.name, from, state)
+	}
+}
+
+// Snapshot is state of breaker at some moment
+type Snapshot struct {
+	Name     string     `json:"name"`
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+// State returns state the breaker would act in now
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.currentState()
+}
+
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.currentState()
+	snapshot := Snapshot{
+		Name:     b.name,
+		State:    state.String(),
+		Failures: b.failures,
+	}
+	if state != Closed {
+		openedAt := b.openedAt
+		snapshot.OpenedAt = &openedAt
+	}
+	return snapshot
+}
+
+// currentState reports open breaker as half-open once timeout passes, as it lets trial calls through
+func (b *Breaker) currentState() State {
+	if b.state == Open && time.Since(b.openedAt) >= b.cfg.OpenTimeout {
+		return HalfOpen
+	}
+	return b.state
+}
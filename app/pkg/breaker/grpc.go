@@ -0,0 +1,37 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor rejects calls with Unavailable code while breaker is open. Calls failed
+// with Unavailable, DeadlineExceeded, Internal or Unknown code count as failures of the upstream.
+// Health checks pass by the breaker, so they show the current state of the upstream.
+func UnaryClientInterceptor(b *Breaker) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if method == grpc_health_v1.Health_Check_FullMethodName {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		done, err := b.Allow()
+		if err != nil {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		switch status.Code(err) {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+			// canceled call says nothing about the upstream, timed out one does
+			done(!errors.Is(ctx.Err(), context.Canceled))
+		default:
+			done(false)
+		}
+		return err
+	}
+}
@@ -0,0 +1,233 @@
+package bolt
+
+import (
+	"encoding/binary"
+	"finance-manager-api-service/pkg/cache"
+	"fmt"
+	"go.etcd.io/bbolt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	sweepInterval = time.Minute
+	// every value is prefixed with expiration unix time, zero means the entry never expires
+	expireAtSize = 8
+)
+
+var bucketName = []byte("cache")
+
+type repository struct {
+	db        *bbolt.DB
+	hitCount  atomic.Int64
+	missCount atomic.Int64
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewCacheRepo opens (or creates) bolt database file. Expired entries are removed lazily on read
+// and by background sweeper.
+func NewCacheRepo(path string) (cache.Repository, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt db: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create bucket: %w", err)
+	}
+
+	r := &repository{
+		db:   db,
+		done: make(chan struct{}),
+	}
+	go r.sweep()
+	return r, nil
+}
+
+func (r *repository) GetIterator() cache.Iterator {
+	var entries []*cache.Entry
+	now := time.Now()
+
+	_ = r.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketName).ForEach(func(k, v []byte) error {
+			value, ok := decode(v, now)
+			if !ok {
+				return nil
+			}
+			entries = append(entries, &cache.Entry{
+				Key:   append([]byte(nil), k...),
+				Value: append([]byte(nil), value...),
+			})
+			return nil
+		})
+	})
+
+	return &iterator{entries: entries}
+}
+
+func (r *repository) Get(uuid []byte) ([]byte, error) {
+	var value []byte
+	var expired bool
+	now := time.Now()
+
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(bucketName).Get(uuid)
+		if v == nil {
+			return cache.ErrNotFound
+		}
+		decoded, ok := decode(v, now)
+		if !ok {
+			expired = true
+			return cache.ErrNotFound
+		}
+		value = append([]byte(nil), decoded...)
+		return nil
+	})
+	if err != nil {
+		if expired {
+			r.Del(uuid)
+		}
+		r.missCount.Add(1)
+		return nil, err
+	}
+
+	r.hitCount.Add(1)
+	return value, nil
+}
+
+func (r *repository) Set(key []byte, value []byte, expireSeconds int) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucketName).Put(key, encode(value, expireSeconds))
+	})
+}
+
+func (r *repository) SetNX(key []byte, value []byte, expireSeconds int) (bool, error) {
+	var set bool
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		if v := b.Get(key); v != nil {
+			if _, ok := decode(v, time.Now()); ok {
+				return nil
+			}
+		}
+		set = true
+		return b.Put(key, encode(value, expireSeconds))
+	})
+	return set && err == nil, err
+}
+
+func (r *repository) Incr(key []byte, expireSeconds int) (int64, error) {
+	var counter int64
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		if v := b.Get(key); v != nil {
+			if value, ok := decode(v, time.Now()); ok {
+				parsed, err := strconv.ParseInt(string(value), 10, 64)
+				if err != nil {
+					return fmt.Errorf("value is not a counter: %w", err)
+				}
+				counter = parsed
+			}
+		}
+		counter++
+		return b.Put(key, encode([]byte(strconv.FormatInt(counter, 10)), expireSeconds))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return counter, nil
+}
+
+func (r *repository) Del(key []byte) (affected bool) {
+	_ = r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		if b.Get(key) == nil {
+			return nil
+		}
+		affected = true
+		return b.Delete(key)
+	})
+	return affected
+}
+
+func (r *repository) EntryCount() int64 {
+	var count int64
+	_ = r.db.View(func(tx *bbolt.Tx) error {
+		count = int64(tx.Bucket(bucketName).Stats().KeyN)
+		return nil
+	})
+	return count
+}
+
+func (r *repository) HitCount() int64 {
+	return r.hitCount.Load()
+}
+
+func (r *repository) MissCount() int64 {
+	return r.missCount.Load()
+}
+
+func (r *repository) Close() error {
+	var err error
+	r.closeOnce.Do(func() {
+		close(r.done)
+		err = r.db.Close()
+	})
+	return err
+}
+
+func (r *repository) sweep() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case now := <-ticker.C:
+			_ = r.db.Update(func(tx *bbolt.Tx) error {
+				c := tx.Bucket(bucketName).Cursor()
+				for k, v := c.First(); k != nil; k, v = c.Next() {
+					if _, ok := decode(v, now); !ok {
+						if err := c.Delete(); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			})
+		}
+	}
+}
+
+func encode(value []byte, expireSeconds int) []byte {
+	var expireAt int64
+	if expireSeconds > 0 {
+		expireAt = time.Now().Add(time.Duration(expireSeconds) * time.Second).Unix()
+	}
+
+	data := make([]byte, expireAtSize+len(value))
+	binary.BigEndian.PutUint64(data, uint64(expireAt))
+	copy(data[expireAtSize:], value)
+	return data
+}
+
+// decode returns stored value and false if entry is malformed or expired
+func decode(data []byte, now time.Time) ([]byte, bool) {
+	if len(data) < expireAtSize {
+		return nil, false
+	}
+	expireAt := int64(binary.BigEndian.Uint64(data))
+	if expireAt != 0 && now.Unix() >= expireAt {
+		return nil, false
+	}
+	return data[expireAtSize:], true
+}
@@ -0,0 +1,21 @@
+package bolt
+
+import (
+	"finance-manager-api-service/pkg/cache"
+)
+
+// iterator walks over snapshot of entries taken when it was created
+type iterator struct {
+	entries []*cache.Entry
+	pos     int
+}
+
+func (it *iterator) Next() *cache.Entry {
+	if it.pos >= len(it.entries) {
+		return nil
+	}
+
+	entry := it.entries[it.pos]
+	it.pos++
+	return entry
+}
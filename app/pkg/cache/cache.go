@@ -1,5 +1,12 @@
 package cache
 
+import (
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("entry not found")
+
 type Repository interface {
 	GetIterator() Iterator
 
@@ -7,9 +14,31 @@ type Repository interface {
 
 	Set(key []byte, value []byte, expireSeconds int) error
 
+	// SetNX sets the value only if the key is absent, it reports whether the value was set.
+	// Check and set are atomic, so only one of concurrent callers sets the key.
+	SetNX(key []byte, value []byte, expireSeconds int) (bool, error)
+
+	// Incr adds one to the counter under key, missing counter starts from zero, and returns the new
+	// value. Increment is atomic, the counter expires in expireSeconds after the last increment.
+	Incr(key []byte, expireSeconds int) (int64, error)
+
 	Del(key []byte) (affected bool)
 
+	// EntryCount is -1 when the store does not count its entries
 	EntryCount() int64
 	HitCount() int64
 	MissCount() int64
+
+	Close() error
+}
+
+// ExpiringSet is implemented by stores that update sets atomically on the server, so processes
+// sharing the store do not overwrite members added by each other. Every member expires on its own,
+// the set expires with its last member.
+type ExpiringSet interface {
+	// AddMember adds member to the set under key and drops expired members
+	AddMember(key, member []byte, expiresAt time.Time) error
+	// Members returns members of the set that have not expired
+	Members(key []byte) ([][]byte, error)
+	RemoveMember(key, member []byte) error
 }
@@ -1,8 +1,11 @@
 package freecache
 
 import (
+	"errors"
 	"finance-manager-api-service/pkg/cache"
+	"fmt"
 	"github.com/coocood/freecache"
+	"strconv"
 	"sync"
 )
 
@@ -23,7 +26,11 @@ func (r *repository) Get(uuid []byte) ([]byte, error) {
 	r.Lock()
 	defer r.Unlock()
 
-	return r.cache.Get(uuid)
+	value, err := r.cache.Get(uuid)
+	if errors.Is(err, freecache.ErrNotFound) {
+		return nil, cache.ErrNotFound
+	}
+	return value, err
 }
 
 func (r *repository) Set(key []byte, value []byte, expireSeconds int) error {
@@ -33,6 +40,35 @@ func (r *repository) Set(key []byte, value []byte, expireSeconds int) error {
 	return r.cache.Set(key, value, expireSeconds)
 }
 
+func (r *repository) SetNX(key []byte, value []byte, expireSeconds int) (bool, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	existing, err := r.cache.GetOrSet(key, value, expireSeconds)
+	return existing == nil && err == nil, err
+}
+
+func (r *repository) Incr(key []byte, expireSeconds int) (int64, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	var counter int64
+	value, err := r.cache.Get(key)
+	switch {
+	case err == nil:
+		if counter, err = strconv.ParseInt(string(value), 10, 64); err != nil {
+			return 0, fmt.Errorf("value is not a counter: %w", err)
+		}
+	case !errors.Is(err, freecache.ErrNotFound):
+		return 0, err
+	}
+	counter++
+	if err = r.cache.Set(key, []byte(strconv.FormatInt(counter, 10)), expireSeconds); err != nil {
+		return 0, err
+	}
+	return counter, nil
+}
+
 func (r *repository) Del(key []byte) (affected bool) {
 	r.Lock()
 	defer r.Unlock()
@@ -60,3 +96,11 @@ func (r *repository) MissCount() int64 {
 
 	return r.cache.MissCount()
 }
+
+func (r *repository) Close() error {
+	r.Lock()
+	defer r.Unlock()
+
+	r.cache.Clear()
+	return nil
+}
@@ -0,0 +1,139 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"finance-manager-api-service/pkg/cache"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	requestWaitTime = 5 * time.Second
+	scanBatchSize   = 100
+)
+
+type repository struct {
+	client    *redis.Client
+	keyPrefix string
+	hitCount  atomic.Int64
+	missCount atomic.Int64
+}
+
+// NewCacheRepo connects to any server speaking redis protocol. All keys are stored with keyPrefix,
+// so the same database can be shared with other applications.
+func NewCacheRepo(addr, password string, db int, keyPrefix string) (cache.Repository, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestWaitTime)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &repository{
+		client:    client,
+		keyPrefix: keyPrefix,
+	}, nil
+}
+
+func (r *repository) GetIterator() cache.Iterator {
+	return &iterator{
+		repo: r,
+		scan: r.client.Scan(context.Background(), 0, r.keyPrefix+"*", scanBatchSize).Iterator(),
+	}
+}
+
+func (r *repository) Get(uuid []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestWaitTime)
+	defer cancel()
+
+	value, err := r.client.Get(ctx, r.key(uuid)).Bytes()
+	if err != nil {
+		r.missCount.Add(1)
+		if errors.Is(err, redis.Nil) {
+			return nil, cache.ErrNotFound
+		}
+		return nil, err
+	}
+
+	r.hitCount.Add(1)
+	return value, nil
+}
+
+func (r *repository) Set(key []byte, value []byte, expireSeconds int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestWaitTime)
+	defer cancel()
+
+	var expiration time.Duration
+	if expireSeconds > 0 {
+		expiration = time.Duration(expireSeconds) * time.Second
+	}
+	return r.client.Set(ctx, r.key(key), value, expiration).Err()
+}
+
+func (r *repository) SetNX(key []byte, value []byte, expireSeconds int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestWaitTime)
+	defer cancel()
+
+	var expiration time.Duration
+	if expireSeconds > 0 {
+		expiration = time.Duration(expireSeconds) * time.Second
+	}
+	return r.client.SetNX(ctx, r.key(key), value, expiration).Result()
+}
+
+func (r *repository) Incr(key []byte, expireSeconds int) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestWaitTime)
+	defer cancel()
+
+	var incr *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, r.key(key))
+		if expireSeconds > 0 {
+			pipe.Expire(ctx, r.key(key), time.Duration(expireSeconds)*time.Second)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (r *repository) Del(key []byte) (affected bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestWaitTime)
+	defer cancel()
+
+	deleted, err := r.client.Del(ctx, r.key(key)).Result()
+	return err == nil && deleted > 0
+}
+
+// EntryCount is not tracked for redis: keys expire on the server and are shared with other
+// processes, counting them would scan the keyspace
+func (r *repository) EntryCount() int64 {
+	return -1
+}
+
+func (r *repository) HitCount() int64 {
+	return r.hitCount.Load()
+}
+
+func (r *repository) MissCount() int64 {
+	return r.missCount.Load()
+}
+
+func (r *repository) Close() error {
+	return r.client.Close()
+}
+
+func (r *repository) key(key []byte) string {
+	return r.keyPrefix + string(key)
+}
@@ -0,0 +1,30 @@
+package redis
+
+import (
+	"context"
+	"finance-manager-api-service/pkg/cache"
+	"github.com/redis/go-redis/v9"
+	"strings"
+)
+
+type iterator struct {
+	repo *repository
+	scan *redis.ScanIterator
+}
+
+func (it *iterator) Next() *cache.Entry {
+	ctx := context.Background()
+	for it.scan.Next(ctx) {
+		key := it.scan.Val()
+		value, err := it.repo.client.Get(ctx, key).Bytes()
+		if err != nil {
+			// key expired or was deleted after it had been scanned
+			continue
+		}
+		return &cache.Entry{
+			Key:   []byte(strings.TrimPrefix(key, it.repo.keyPrefix)),
+			Value: value,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// addMemberScript keeps set as hash of member to its expiration unix time. The member is added,
+// expired members are dropped and the key expires with the last member in one atomic step.
+var addMemberScript = redis.NewScript(`
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+local now = tonumber(ARGV[3])
+local latest = 0
+local entries = redis.call('HGETALL', KEYS[1])
+for i = 1, #entries, 2 do
+	local expiresAt = tonumber(entries[i + 1])
+	if expiresAt == nil or expiresAt <= now then
+		redis.call('HDEL', KEYS[1], entries[i])
+	elseif expiresAt > latest then
+		latest = expiresAt
+	end
+end
+if latest > 0 then
+	redis.call('EXPIREAT', KEYS[1], latest)
+end
+return latest
+`)
+
+func (r *repository) AddMember(key, member []byte, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestWaitTime)
+	defer cancel()
+
+	return addMemberScript.Run(ctx, r.client, []string{r.key(key)},
+		string(member), expiresAt.Unix(), time.Now().Unix()).Err()
+}
+
+func (r *repository) Members(key []byte) ([][]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestWaitTime)
+	defer cancel()
+
+	entries, err := r.client.HGetAll(ctx, r.key(key)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	members := make([][]byte, 0, len(entries))
+	for member, value := range entries {
+		expiresAt, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || expiresAt <= now {
+			continue
+		}
+		members = append(members, []byte(member))
+	}
+	return members, nil
+}
+
+func (r *repository) RemoveMember(key, member []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestWaitTime)
+	defer cancel()
+
+	return r.client.HDel(ctx, r.key(key), string(member)).Err()
+}
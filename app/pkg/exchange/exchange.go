@@ -0,0 +1,15 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"math/big"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// RateProvider returns exchange rates, amount in "from" currency multiplied by the rate
+// is amount in "to" currency
+type RateProvider interface {
+	Rate(ctx context.Context, from, to string) (*big.Rat, error)
+}
@@ -0,0 +1,155 @@
+package exchange
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"finance-manager-api-service/pkg/money"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type pair struct {
+	from, to string
+}
+
+// fileProvider serves rates loaded from file once on start
+type fileProvider struct {
+	rates map[pair]*big.Rat
+	// currencies tried in order to derive cross rate, so the result does not depend on map order
+	pivots []string
+}
+
+// jsonRates is format of JSON rates file: {"base": "USD", "rates": {"EUR": "0.92", "GBP": 0.79}}
+type jsonRates struct {
+	Base  string                 `json:"base"`
+	Rates map[string]json.Number `json:"rates"`
+}
+
+// NewFileProvider loads rates from .json file or from .csv file with "from,to,rate" lines.
+// Reverse rates and rates through common currency are derived from the loaded ones: the base
+// currency is tried first, then base of JSON file, then other currencies in alphabetical order.
+// Empty path gives provider that knows only rate of currency to itself.
+func NewFileProvider(path, base string) (RateProvider, error) {
+	p := &fileProvider{rates: make(map[pair]*big.Rat)}
+	if path == "" {
+		return p, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open exchange rates file: %w", err)
+	}
+	defer file.Close()
+
+	var fileBase string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		err = p.loadCSV(file)
+	case ".json":
+		fileBase, err = p.loadJSON(file)
+	default:
+		err = errors.New("unsupported format, expected .csv or .json")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exchange rates from %s: %w", path, err)
+	}
+	p.setPivots(base, fileBase)
+	return p, nil
+}
+
+func (p *fileProvider) setPivots(preferred ...string) {
+	var others []string
+	for rate := range p.rates {
+		if !slices.Contains(others, rate.from) {
+			others = append(others, rate.from)
+		}
+	}
+	slices.Sort(others)
+
+	for _, currency := range preferred {
+		if i := slices.Index(others, currency); i >= 0 {
+			p.pivots = append(p.pivots, currency)
+			others = slices.Delete(others, i, i+1)
+		}
+	}
+	p.pivots = append(p.pivots, others...)
+}
+
+func (p *fileProvider) loadCSV(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], "from") {
+			continue
+		}
+		if err = p.add(record[0], record[1], record[2]); err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// loadJSON returns base currency of the file
+func (p *fileProvider) loadJSON(r io.Reader) (string, error) {
+	var jr jsonRates
+	if err := json.NewDecoder(r).Decode(&jr); err != nil {
+		return "", err
+	}
+	for currency, rate := range jr.Rates {
+		if err := p.add(jr.Base, currency, rate.String()); err != nil {
+			return "", err
+		}
+	}
+	return jr.Base, nil
+}
+
+func (p *fileProvider) add(from, to, rate string) error {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if !money.IsCurrencyCode(from) || !money.IsCurrencyCode(to) {
+		return fmt.Errorf("invalid currency pair %s/%s", from, to)
+	}
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok || r.Sign() <= 0 {
+		return fmt.Errorf("invalid rate %q for %s/%s", rate, from, to)
+	}
+
+	p.rates[pair{from, to}] = r
+	// explicitly listed reverse rate wins over the derived one
+	if _, ok = p.rates[pair{to, from}]; !ok {
+		p.rates[pair{to, from}] = new(big.Rat).Inv(r)
+	}
+	return nil
+}
+
+func (p *fileProvider) Rate(_ context.Context, from, to string) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	if rate, ok := p.rates[pair{from, to}]; ok {
+		return new(big.Rat).Set(rate), nil
+	}
+	for _, via := range p.pivots {
+		rate, ok := p.rates[pair{from, via}]
+		if !ok {
+			continue
+		}
+		if next, ok := p.rates[pair{via, to}]; ok {
+			return new(big.Rat).Mul(rate, next), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s to %s", ErrRateNotFound, from, to)
+}
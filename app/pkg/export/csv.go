@@ -0,0 +1,80 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// rows are flushed in chunks, so the client starts receiving the file before it is complete
+const csvFlushRows = 500
+
+// text starting with these characters is taken for a formula by spreadsheet applications
+const formulaPrefixes = "=+-@\t\r"
+
+type csvWriter struct {
+	w      *csv.Writer
+	tables []Table
+	table  int
+	rows   int
+}
+
+func newCSVWriter(w io.Writer, tables []Table) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), tables: tables}
+	if err := cw.writeHeader(); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) writeHeader() error {
+	columns := cw.tables[cw.table].Columns
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Title
+	}
+	return cw.w.Write(header)
+}
+
+func (cw *csvWriter) WriteRow(cells ...Cell) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		record[i] = cell.Value
+		if !cell.Numeric && strings.ContainsRune(formulaPrefixes, firstRune(cell.Value)) {
+			record[i] = "'" + cell.Value
+		}
+	}
+	if err := cw.w.Write(record); err != nil {
+		return err
+	}
+
+	cw.rows++
+	if cw.rows%csvFlushRows == 0 {
+		cw.w.Flush()
+		return cw.w.Error()
+	}
+	return nil
+}
+
+// NextTable separates tables with empty line and writes header of the next table
+func (cw *csvWriter) NextTable() error {
+	if cw.table+1 >= len(cw.tables) {
+		return ErrNoNextTable
+	}
+	cw.table++
+	if err := cw.w.Write([]string{""}); err != nil {
+		return err
+	}
+	return cw.writeHeader()
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
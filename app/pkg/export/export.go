@@ -0,0 +1,95 @@
+package export
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported export format")
+	ErrNoTables          = errors.New("document has no tables")
+	ErrNoNextTable       = errors.New("document has no next table")
+)
+
+var contentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatPDF:  "application/pdf",
+}
+
+// Table describes section of exported document. Title and Notes are printed above the rows in PDF
+// only, in XLSX every table is a sheet named by the title, in CSV tables are separated by empty line.
+type Table struct {
+	Title   string
+	Notes   []string
+	Columns []Column
+}
+
+type Column struct {
+	Title string
+	// width in characters, used for XLSX column widths and PDF layout
+	Width int
+}
+
+// Cell is value of the row. Numeric cells hold decimal number like "-12.50"
+type Cell struct {
+	Value   string
+	Numeric bool
+}
+
+func Text(value string) Cell {
+	return Cell{Value: value}
+}
+
+func Number(value string) Cell {
+	return Cell{Value: value, Numeric: true}
+}
+
+// Writer encodes rows as they are written, so large documents are never kept in memory. Rows go
+// to the first table of the document until NextTable moves to the next one.
+// Close must be called to complete the document, it does not close the underlying writer.
+type Writer interface {
+	WriteRow(cells ...Cell) error
+	NextTable() error
+	Close() error
+}
+
+func NewWriter(format string, w io.Writer, tables ...Table) (Writer, error) {
+	if len(tables) == 0 {
+		return nil, ErrNoTables
+	}
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, tables)
+	case FormatXLSX:
+		return newXLSXWriter(w, tables)
+	case FormatPDF:
+		return newPDFWriter(w, tables)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// ContentType returns media type of format, empty for unsupported one
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// FormatOf returns export format of media type, empty for unsupported one
+func FormatOf(mediaType string) string {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for format, contentType := range contentTypes {
+		supported, _, _ := strings.Cut(contentType, ";")
+		if mediaType == supported {
+			return format
+		}
+	}
+	return ""
+}
@@ -0,0 +1,282 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A4 portrait page, sizes in points
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 40.0
+	pdfFontSize   = 9.0
+	pdfLineHeight = 13.0
+	pdfCellPad    = 4.0
+)
+
+// object numbers reserved for objects referenced before they are written
+const (
+	pdfCatalogObj = iota + 1
+	pdfPagesObj
+	pdfFontObj
+	pdfBoldFontObj
+	pdfFirstFreeObj
+)
+
+// pdfWriter writes statement with the standard Helvetica font, so no font is embedded.
+// Every page is written out as soon as it is full, only objects offsets are kept till Close.
+// Standard fonts cover Latin-1, other characters are replaced with '?'.
+type pdfWriter struct {
+	w       *bufio.Writer
+	written int64
+	tables  []Table
+	table   Table
+	widths  []float64
+	offsets []int64
+	pages   []int
+	content bytes.Buffer
+	y       float64
+}
+
+func newPDFWriter(w io.Writer, tables []Table) (*pdfWriter, error) {
+	pw := &pdfWriter{
+		w:       bufio.NewWriter(w),
+		tables:  tables[1:],
+		offsets: make([]int64, pdfFirstFreeObj),
+	}
+
+	pw.print("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	pw.object(pdfFontObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	pw.object(pdfBoldFontObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	pw.newPage()
+	pw.y = pdfPageHeight - pdfMargin
+	pw.startTable(tables[0])
+	return pw, pw.w.Flush()
+}
+
+// startTable writes title, notes and header of the table at the current position
+func (pw *pdfWriter) startTable(table Table) {
+	pw.table = table
+	pw.widths = columnWidths(table.Columns)
+
+	y := pw.y - 14
+	if table.Title != "" {
+		pw.text(pdfMargin, y, "F2", 14, table.Title)
+		y -= 24
+	}
+	for _, note := range table.Notes {
+		pw.text(pdfMargin, y, "F1", 10, note)
+		y -= 14
+	}
+	pw.y = y - 6
+	pw.writeHeader()
+}
+
+// tableHeight is height of title, notes, header and first row of the table
+func tableHeight(table Table) float64 {
+	height := 14 + 6 + 2*pdfLineHeight + 14*float64(len(table.Notes))
+	if table.Title != "" {
+		height += 24
+	}
+	return height
+}
+
+func (pw *pdfWriter) WriteRow(cells ...Cell) error {
+	if pw.y < pdfMargin+pdfLineHeight {
+		if err := pw.endPage(); err != nil {
+			return err
+		}
+		pw.newPage()
+		pw.y = pdfPageHeight - pdfMargin - pdfLineHeight
+		pw.writeHeader()
+	}
+	pw.writeCells(cells, "F1")
+	return nil
+}
+
+// NextTable continues on the current page after a gap when the beginning of the table fits there
+func (pw *pdfWriter) NextTable() error {
+	if len(pw.tables) == 0 {
+		return ErrNoNextTable
+	}
+	table := pw.tables[0]
+	pw.tables = pw.tables[1:]
+
+	pw.y -= pdfLineHeight
+	if pw.y-tableHeight(table) < pdfMargin {
+		if err := pw.endPage(); err != nil {
+			return err
+		}
+		pw.newPage()
+		pw.y = pdfPageHeight - pdfMargin
+	}
+	pw.startTable(table)
+	return nil
+}
+
+func (pw *pdfWriter) Close() error {
+	if err := pw.endPage(); err != nil {
+		return err
+	}
+
+	kids := make([]string, len(pw.pages))
+	for i, page := range pw.pages {
+		kids[i] = strconv.Itoa(page) + " 0 R"
+	}
+	pw.object(pdfPagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pw.pages)))
+	pw.object(pdfCatalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPagesObj))
+
+	xref := pw.written
+	pw.print(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)))
+	for _, offset := range pw.offsets[1:] {
+		pw.print(fmt.Sprintf("%010d 00000 n \n", offset))
+	}
+	pw.print(fmt.Sprintf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pw.offsets), pdfCatalogObj, xref))
+	return pw.w.Flush()
+}
+
+func (pw *pdfWriter) writeHeader() {
+	header := make([]Cell, len(pw.table.Columns))
+	for i, column := range pw.table.Columns {
+		header[i] = Text(column.Title)
+	}
+	pw.writeCells(header, "F2")
+	lineY := pw.y + pdfLineHeight - 3
+	fmt.Fprintf(&pw.content, "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, lineY, pdfPageWidth-pdfMargin, lineY)
+}
+
+func (pw *pdfWriter) writeCells(cells []Cell, font string) {
+	x := pdfMargin
+	for i, width := range pw.widths {
+		if i < len(cells) {
+			value := fitText(cells[i].Value, width-pdfCellPad, pdfFontSize)
+			cellX := x
+			if cells[i].Numeric {
+				cellX = x + width - pdfCellPad - textWidth(value, pdfFontSize)
+			}
+			pw.text(cellX, pw.y, font, pdfFontSize, value)
+		}
+		x += width
+	}
+	pw.y -= pdfLineHeight
+}
+
+func (pw *pdfWriter) text(x, y float64, font string, size float64, value string) {
+	fmt.Fprintf(&pw.content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(value))
+}
+
+func (pw *pdfWriter) newPage() {
+	pw.content.Reset()
+}
+
+// endPage writes content stream and page object of the current page
+func (pw *pdfWriter) endPage() error {
+	footer := "Page " + strconv.Itoa(len(pw.pages)+1)
+	pw.text(pdfPageWidth-pdfMargin-textWidth(footer, 8), pdfMargin/2, "F1", 8, footer)
+
+	contentObj := pw.nextObject()
+	pw.object(contentObj, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", pw.content.Len(), pw.content.String()))
+
+	pageObj := pw.nextObject()
+	pw.object(pageObj, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %g %g] "+
+		"/Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPagesObj, pdfPageWidth, pdfPageHeight, pdfFontObj, pdfBoldFontObj, contentObj))
+	pw.pages = append(pw.pages, pageObj)
+	return pw.w.Flush()
+}
+
+func (pw *pdfWriter) nextObject() int {
+	pw.offsets = append(pw.offsets, 0)
+	return len(pw.offsets) - 1
+}
+
+func (pw *pdfWriter) object(number int, body string) {
+	pw.offsets[number] = pw.written
+	pw.print(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", number, body))
+}
+
+// print writes to buffer, write error is returned by the next Flush
+func (pw *pdfWriter) print(s string) {
+	n, _ := pw.w.WriteString(s)
+	pw.written += int64(n)
+}
+
+// columnWidths scales widths of columns to width of the page
+func columnWidths(columns []Column) []float64 {
+	total := 0
+	for _, column := range columns {
+		total += max(column.Width, 1)
+	}
+	widths := make([]float64, len(columns))
+	for i, column := range columns {
+		widths[i] = (pdfPageWidth - 2*pdfMargin) * float64(max(column.Width, 1)) / float64(total)
+	}
+	return widths
+}
+
+// widths of Helvetica glyphs from ' ' to '~' in 1/1000 of font size
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+func textWidth(s string, size float64) float64 {
+	width := 0
+	for _, r := range s {
+		if r >= ' ' && r <= '~' {
+			width += helveticaWidths[r-' ']
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// fitText cuts text which does not fit into width and marks the cut with ellipsis
+func fitText(s string, width, size float64) string {
+	if textWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(string(runes)+"…", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// WinAnsiEncoding codes of characters outside of Latin-1
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// pdfString encodes text as WinAnsi literal string
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case winAnsi[r] != 0:
+			fmt.Fprintf(&b, "\\%03o", winAnsi[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,222 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`%s` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`
+	xlsxSheetContentType = `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`
+	xlsxRels             = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`%s` +
+		`<Relationship Id="rIdStyles" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`
+	xlsxSheetRel = `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`
+	// style 1 is bold header, style 2 is number with two decimals
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+		`</styleSheet>`
+)
+
+// characters that are not allowed in names of sheets
+const xlsxSheetNameForbidden = `[]:*?/\`
+
+// xlsxWriter writes workbook with sheet per table. Sheets are declared in the workbook up front and
+// written one after another as the last parts of the archive, so rows go straight to the zip stream.
+// Strings are inlined to avoid shared strings table that could only be written after all rows.
+type xlsxWriter struct {
+	zip    *zip.Writer
+	tables []Table
+	table  int
+	sheet  *bufio.Writer
+	row    int
+}
+
+func newXLSXWriter(w io.Writer, tables []Table) (*xlsxWriter, error) {
+	xw := &xlsxWriter{zip: zip.NewWriter(w), tables: tables}
+
+	var contentTypes, sheets, rels strings.Builder
+	names := make(map[string]bool)
+	for i, table := range tables {
+		name := sheetName(table.Title, i, names)
+		contentTypes.WriteString(fmt.Sprintf(xlsxSheetContentType, i+1))
+		sheets.WriteString(`<sheet name="` + escapeXML(name) + `" sheetId="` + strconv.Itoa(i+1) +
+			`" r:id="rId` + strconv.Itoa(i+1) + `"/>`)
+		rels.WriteString(fmt.Sprintf(xlsxSheetRel, i+1, i+1))
+	}
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets>` + sheets.String() + `</sheets></workbook>`
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", fmt.Sprintf(xlsxContentTypes, contentTypes.String())},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", fmt.Sprintf(xlsxWorkbookRels, rels.String())},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		fw, err := xw.zip.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(fw, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := xw.startSheet(); err != nil {
+		return nil, err
+	}
+	return xw, nil
+}
+
+// sheetName returns title of the table fitted to rules of sheet names, unique within the workbook
+func sheetName(title string, i int, used map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(xlsxSheetNameForbidden, r) {
+			return '_'
+		}
+		return r
+	}, strings.Trim(title, "'"))
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" || used[strings.ToLower(name)] {
+		name = "Sheet" + strconv.Itoa(i+1)
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// startSheet creates part of the current table and writes its header
+func (xw *xlsxWriter) startSheet() error {
+	fw, err := xw.zip.Create("xl/worksheets/sheet" + strconv.Itoa(xw.table+1) + ".xml")
+	if err != nil {
+		return err
+	}
+	xw.sheet = bufio.NewWriter(fw)
+	xw.row = 0
+
+	columns := xw.tables[xw.table].Columns
+	_, _ = xw.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	_, _ = xw.sheet.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" state="frozen"/></sheetView></sheetViews><cols>`)
+	for i, column := range columns {
+		width := column.Width
+		if width <= 0 {
+			width = 10
+		}
+		n := strconv.Itoa(i + 1)
+		_, _ = xw.sheet.WriteString(`<col min="` + n + `" max="` + n + `" width="` + strconv.Itoa(width+2) + `" customWidth="1"/>`)
+	}
+	_, _ = xw.sheet.WriteString(`</cols><sheetData>`)
+
+	header := make([]Cell, len(columns))
+	for i, column := range columns {
+		header[i] = Text(column.Title)
+	}
+	return xw.writeRow(header, 1)
+}
+
+func (xw *xlsxWriter) endSheet() error {
+	_, _ = xw.sheet.WriteString(`</sheetData></worksheet>`)
+	return xw.sheet.Flush()
+}
+
+func (xw *xlsxWriter) WriteRow(cells ...Cell) error {
+	return xw.writeRow(cells, 0)
+}
+
+func (xw *xlsxWriter) writeRow(cells []Cell, textStyle int) error {
+	xw.row++
+	row := strconv.Itoa(xw.row)
+	_, _ = xw.sheet.WriteString(`<row r="` + row + `">`)
+	for i, cell := range cells {
+		ref := columnName(i) + row
+		switch {
+		case cell.Value == "":
+			continue
+		case cell.Numeric:
+			_, _ = xw.sheet.WriteString(`<c r="` + ref + `" s="2"><v>` + escapeXML(cell.Value) + `</v></c>`)
+		default:
+			_, _ = xw.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"` + styleAttr(textStyle) + `><is><t xml:space="preserve">` +
+				escapeXML(cell.Value) + `</t></is></c>`)
+		}
+	}
+	_, err := xw.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (xw *xlsxWriter) NextTable() error {
+	if xw.table+1 >= len(xw.tables) {
+		return ErrNoNextTable
+	}
+	if err := xw.endSheet(); err != nil {
+		return err
+	}
+	xw.table++
+	return xw.startSheet()
+}
+
+// Close writes sheets of the tables that were not reached with header only, every declared sheet
+// must be present in the archive
+func (xw *xlsxWriter) Close() error {
+	for xw.table+1 < len(xw.tables) {
+		if err := xw.NextTable(); err != nil {
+			return err
+		}
+	}
+	if err := xw.endSheet(); err != nil {
+		return err
+	}
+	return xw.zip.Close()
+}
+
+func styleAttr(style int) string {
+	if style == 0 {
+		return ""
+	}
+	return ` s="` + strconv.Itoa(style) + `"`
+}
+
+// columnName returns spreadsheet name of column by zero-based index: A, B, ..., Z, AA, AB, ...
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
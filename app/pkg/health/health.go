@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Dependency is upstream the application needs to serve requests
+type Dependency struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type DependencyStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status       string             `json:"status"`
+	CheckedAt    time.Time          `json:"checked_at"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// Probe checks all dependencies at the same time, each within Timeout. Report is cached for
+// CacheTTL, so frequent probes of orchestrator do not load upstream services.
+type Probe struct {
+	dependencies []Dependency
+	timeout      time.Duration
+	cacheTTL     time.Duration
+
+	mu     sync.Mutex
+	report Report
+}
+
+func NewProbe(timeout, cacheTTL time.Duration, dependencies ...Dependency) *Probe {
+	return &Probe{
+		dependencies: dependencies,
+		timeout:      timeout,
+		cacheTTL:     cacheTTL,
+	}
+}
+
+// Check returns cached report or checks dependencies. Concurrent callers wait for the running
+// check instead of starting their own.
+func (p *Probe) Check(ctx context.Context) Report {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.report.CheckedAt.IsZero() && time.Since(p.report.CheckedAt) < p.cacheTTL {
+		return p.report
+	}
+
+	report := Report{
+		Status:       StatusUp,
+		Dependencies: make([]DependencyStatus, len(p.dependencies)),
+	}
+	var wg sync.WaitGroup
+	for i, dependency := range p.dependencies {
+		wg.Add(1)
+		go func(i int, dependency Dependency) {
+			defer wg.Done()
+			report.Dependencies[i] = p.check(ctx, dependency)
+		}(i, dependency)
+	}
+	wg.Wait()
+	report.CheckedAt = time.Now()
+
+	for _, dependency := range report.Dependencies {
+		if dependency.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	// check interrupted by the caller says nothing about dependencies
+	if ctx.Err() == nil {
+		p.report = report
+	}
+	return report
+}
+
+func (p *Probe) check(ctx context.Context, dependency Dependency) DependencyStatus {
+	checkCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dependency.Check(checkCtx)
+	status := DependencyStatus{
+		Name:      dependency.Name,
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
@@ -0,0 +1,108 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	h "finance-manager-api-service/internal/handler"
+	"finance-manager-api-service/pkg/logging"
+	"github.com/julienschmidt/httprouter"
+	"math/big"
+	"net/http"
+)
+
+const (
+	jwksURL = "/.well-known/jwks.json"
+)
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns all public verification keys. Symmetric keys are never published.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: make([]JWK, 0, len(ks.publicKeys))}
+	for _, pk := range ks.publicKeys {
+		jwk := JWK{
+			KeyID:     pk.keyID,
+			Use:       "sig",
+			Algorithm: pk.algorithm.String(),
+		}
+		switch key := pk.key.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = encodeBase64URL(key.N.Bytes())
+			jwk.E = encodeBase64URL(big.NewInt(int64(key.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (key.Curve.Params().BitSize + 7) / 8
+			jwk.KeyType = "EC"
+			jwk.Curve = key.Curve.Params().Name
+			jwk.X = encodeBase64URL(key.X.FillBytes(make([]byte, size)))
+			jwk.Y = encodeBase64URL(key.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = encodeBase64URL(key)
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+func encodeBase64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+type jwksHandler struct {
+	Logger *logging.Logger
+	Keys   *KeySet
+}
+
+func NewJWKSHandler(logger *logging.Logger, keys *KeySet) h.Handler {
+	return &jwksHandler{
+		Logger: logger,
+		Keys:   keys,
+	}
+}
+
+func (h *jwksHandler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, jwksURL, h.GetJWKS)
+}
+
+// GetJWKS
+// @Summary 	JSON Web Key Set
+// @Description Public keys that can be used to verify access tokens issued by the service
+// @Tags 		Auth
+// @Produce 	json
+// @Success 	200 	{object} jwt.JWKS "Key set"
+// @Router 		/.well-known/jwks.json [get]
+func (h *jwksHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	jwksBytes, err := json.Marshal(h.Keys.JWKS())
+	if err != nil {
+		h.Logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(jwksBytes)
+}
@@ -8,9 +8,12 @@ import (
 	"finance-manager-api-service/pkg/logging"
 	"github.com/cristalhq/jwt/v3"
 	"github.com/google/uuid"
+	"sync"
 	"time"
 )
 
+const accessTokenTTL = 60 * time.Minute
+
 type TokenAndRefreshToken struct {
 	Token        string `json:"token"`
 	RefreshToken string `json:"refresh_token"`
@@ -21,8 +24,12 @@ type RefreshToken struct {
 }
 
 type Helper interface {
-	GenerateAccessToken(u user_service.User) ([]byte, error)
-	UpdateRefreshToken(rt RefreshToken) ([]byte, error)
+	GenerateAccessToken(u user_service.User, client Client) ([]byte, error)
+	UpdateRefreshToken(rt RefreshToken, client Client) ([]byte, error)
+	RevokeRefreshToken(rt RefreshToken) error
+	RevokeAllRefreshTokens(userUUID string) error
+	RevokeAccessToken(claims UserClaims) error
+	RevokeUserSessions(userUUID string) error
 }
 
 type UserClaims struct {
@@ -30,30 +37,79 @@ type UserClaims struct {
 	Email string `json:"email"`
 }
 
+// UserUUID returns token's subject. Tokens issued by previous versions kept user's uuid in "jti".
+func (uc UserClaims) UserUUID() string {
+	if uc.Subject != "" {
+		return uc.Subject
+	}
+	return uc.ID
+}
+
 type helper struct {
-	RTCache cache.Repository
-	logger  *logging.Logger
+	RTCache     cache.Repository
+	Revocations RevocationList
+	logger      *logging.Logger
+	// refresh token family can not live longer than absoluteTTL since login,
+	// single refresh token expires if it is not used during idleTTL
+	absoluteTTL time.Duration
+	idleTTL     time.Duration
+	// guards read-modify-write of user's token families index in stores local to the process,
+	// shared stores implement cache.ExpiringSet
+	indexMu *sync.Mutex
 }
 
-func NewHelper(rtCache cache.Repository, logger *logging.Logger) Helper {
-	return &helper{RTCache: rtCache, logger: logger}
+func NewHelper(rtCache cache.Repository, revocations RevocationList, logger *logging.Logger) Helper {
+	cfg := config.GetConfig()
+	return &helper{
+		RTCache:     rtCache,
+		Revocations: revocations,
+		logger:      logger,
+		absoluteTTL: cfg.JWT.RefreshTokenAbsoluteTTL,
+		idleTTL:     cfg.JWT.RefreshTokenIdleTTL,
+		indexMu:     &sync.Mutex{},
+	}
+}
+
+// GenerateAccessToken is called on login and starts new refresh token family
+func (h helper) GenerateAccessToken(u user_service.User, client Client) ([]byte, error) {
+	now := time.Now()
+	family := tokenFamily{
+		ID: uuid.New().String(),
+		Session: Session{
+			UserUUID: u.UUID,
+			Email:    u.Email,
+			IssuedAt: now,
+			Client:   client,
+		},
+		ExpiresAt: now.Add(h.absoluteTTL),
+	}
+	return h.generateTokens(family)
 }
 
-func (h helper) GenerateAccessToken(u user_service.User) ([]byte, error) {
-	key := []byte(config.GetConfig().JWT.Secret)
-	signer, err := jwt.NewSignerHS(jwt.HS256, key)
+func (h helper) UpdateRefreshToken(rt RefreshToken, client Client) ([]byte, error) {
+	family, err := h.useRefreshToken(rt.RefreshToken)
 	if err != nil {
 		return nil, err
 	}
-	builder := jwt.NewBuilder(signer)
+	refreshedAt := time.Now()
+	family.Session.RefreshedAt = &refreshedAt
+	family.Session.Client = client
+	return h.generateTokens(family)
+}
+
+func (h helper) generateTokens(family tokenFamily) ([]byte, error) {
+	builder := GetKeySet().Builder()
 
+	now := time.Now()
 	claims := UserClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ID:        u.UUID,
+			ID:        uuid.New().String(),
+			Subject:   family.Session.UserUUID,
 			Audience:  []string{"users"},
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(60 * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
 		},
-		Email: u.Email,
+		Email: family.Session.Email,
 	}
 
 	token, err := builder.Build(claims)
@@ -62,9 +118,7 @@ func (h helper) GenerateAccessToken(u user_service.User) ([]byte, error) {
 	}
 
 	h.logger.Info("create token")
-	refreshTokenUUID := uuid.New()
-	userBytes, _ := json.Marshal(u)
-	err = h.RTCache.Set([]byte(refreshTokenUUID.String()), userBytes, -1)
+	refreshToken, err := h.saveRefreshToken(family)
 	if err != nil {
 		h.logger.Error(err)
 		return nil, err
@@ -72,7 +126,7 @@ func (h helper) GenerateAccessToken(u user_service.User) ([]byte, error) {
 
 	jsonByte, err := json.Marshal(map[string]string{
 		"token":         token.String(),
-		"refresh_token": refreshTokenUUID.String(),
+		"refresh_token": refreshToken,
 	})
 	if err != nil {
 		return nil, err
@@ -81,19 +135,18 @@ func (h helper) GenerateAccessToken(u user_service.User) ([]byte, error) {
 	return jsonByte, nil
 }
 
-func (h helper) UpdateRefreshToken(rt RefreshToken) ([]byte, error) {
-	defer h.RTCache.Del([]byte(rt.RefreshToken))
-
-	userBytes, err := h.RTCache.Get([]byte(rt.RefreshToken))
-	if err != nil {
-		return nil, err
+func (h helper) RevokeAccessToken(claims UserClaims) error {
+	if claims.ExpiresAt == nil {
+		return nil
 	}
+	return h.Revocations.RevokeToken(claims.ID, claims.ExpiresAt.Time)
+}
 
-	var u user_service.User
-	err = json.Unmarshal(userBytes, &u)
-	if err != nil {
-		return nil, err
+// RevokeUserSessions invalidates all access and refresh tokens of the user issued so far
+func (h helper) RevokeUserSessions(userUUID string) error {
+	h.logger.Infof("revoke all sessions of user %s", userUUID)
+	if err := h.Revocations.RevokeIssuedBefore(userUUID, time.Now()); err != nil {
+		return err
 	}
-
-	return h.GenerateAccessToken(u)
+	return h.RevokeAllRefreshTokens(userUUID)
 }
@@ -0,0 +1,258 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"finance-manager-api-service/internal/config"
+	"finance-manager-api-service/pkg/logging"
+	"fmt"
+	"github.com/cristalhq/jwt/v3"
+	"os"
+	"strings"
+	"sync"
+)
+
+// KeySet holds the key used to sign new access tokens and all keys that are accepted
+// during verification. Keeping previous public keys allows rotating the signing key
+// without invalidating tokens that were issued before the rotation.
+type KeySet struct {
+	signingKeyID string
+	signer       jwt.Signer
+	verifiers    map[string]jwt.Verifier
+	publicKeys   []publicKey
+}
+
+type publicKey struct {
+	keyID     string
+	algorithm jwt.Algorithm
+	key       crypto.PublicKey
+}
+
+var keySet *KeySet
+var keySetOnce sync.Once
+
+// GetKeySet loads keys described in config.JWT once and returns them
+func GetKeySet() *KeySet {
+	keySetOnce.Do(func() {
+		logger := logging.GetLogger()
+		logger.Info("load jwt keys")
+		var err error
+		keySet, err = NewKeySet(config.GetConfig())
+		if err != nil {
+			logger.Fatal(err)
+		}
+	})
+	return keySet
+}
+
+func NewKeySet(cfg *config.Config) (*KeySet, error) {
+	ks := &KeySet{
+		signingKeyID: cfg.JWT.KeyID,
+		verifiers:    make(map[string]jwt.Verifier),
+	}
+	alg := jwt.Algorithm(cfg.JWT.Algorithm)
+
+	switch alg {
+	case jwt.HS256, jwt.HS384, jwt.HS512:
+		if cfg.JWT.Secret == "" {
+			return nil, fmt.Errorf("jwt secret is required for %s", alg)
+		}
+		signer, err := jwt.NewSignerHS(alg, []byte(cfg.JWT.Secret))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s signer: %w", alg, err)
+		}
+		verifier, err := jwt.NewVerifierHS(alg, []byte(cfg.JWT.Secret))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s verifier: %w", alg, err)
+		}
+		ks.signer = signer
+		ks.verifiers[ks.signingKeyID] = verifier
+	default:
+		privateKey, err := readPrivateKey(cfg.JWT.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := newSigner(alg, privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s signer: %w", alg, err)
+		}
+		if err = ks.addPublicKey(ks.signingKeyID, alg, privateKey.Public()); err != nil {
+			return nil, err
+		}
+		ks.signer = signer
+	}
+
+	for _, vk := range cfg.JWT.VerificationKeys {
+		if vk.KeyID == "" {
+			return nil, errors.New("verification key must have key_id")
+		}
+		if _, ok := ks.verifiers[vk.KeyID]; ok {
+			return nil, fmt.Errorf("duplicate jwt key id: %s", vk.KeyID)
+		}
+		key, err := readPublicKey(vk.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if err = ks.addPublicKey(vk.KeyID, jwt.Algorithm(vk.Algorithm), key); err != nil {
+			return nil, err
+		}
+	}
+
+	return ks, nil
+}
+
+func (ks *KeySet) addPublicKey(keyID string, alg jwt.Algorithm, key crypto.PublicKey) error {
+	verifier, err := newVerifier(alg, key)
+	if err != nil {
+		return fmt.Errorf("failed to create %s verifier for key %q: %w", alg, keyID, err)
+	}
+	ks.verifiers[keyID] = verifier
+	ks.publicKeys = append(ks.publicKeys, publicKey{keyID: keyID, algorithm: alg, key: key})
+	return nil
+}
+
+// Builder returns token builder which signs tokens with the current signing key
+func (ks *KeySet) Builder() *jwt.Builder {
+	if ks.signingKeyID == "" {
+		return jwt.NewBuilder(ks.signer)
+	}
+	return jwt.NewBuilder(ks.signer, jwt.WithKeyID(ks.signingKeyID))
+}
+
+// ParseAndVerify picks verification key by "kid" header and checks token's signature.
+// Tokens without "kid" are verified with the current signing key.
+func (ks *KeySet) ParseAndVerify(rawToken string) (*jwt.Token, error) {
+	token, err := jwt.ParseString(rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	keyID := token.Header().KeyID
+	if keyID == "" {
+		keyID = ks.signingKeyID
+	}
+	verifier, ok := ks.verifiers[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown jwt key id: %q", keyID)
+	}
+
+	if token.Header().Algorithm != verifier.Algorithm() {
+		return nil, jwt.ErrAlgorithmMismatch
+	}
+	if err = verifier.Verify(token.Payload(), token.Signature()); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func newSigner(alg jwt.Algorithm, key crypto.Signer) (jwt.Signer, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if strings.HasPrefix(alg.String(), "PS") {
+			return jwt.NewSignerPS(alg, k)
+		}
+		return jwt.NewSignerRS(alg, k)
+	case *ecdsa.PrivateKey:
+		return jwt.NewSignerES(alg, k)
+	case ed25519.PrivateKey:
+		if alg != jwt.EdDSA {
+			return nil, jwt.ErrUnsupportedAlg
+		}
+		return jwt.NewSignerEdDSA(k)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+func newVerifier(alg jwt.Algorithm, key crypto.PublicKey) (jwt.Verifier, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(alg.String(), "PS") {
+			return jwt.NewVerifierPS(alg, k)
+		}
+		return jwt.NewVerifierRS(alg, k)
+	case *ecdsa.PublicKey:
+		return jwt.NewVerifierES(alg, k)
+	case ed25519.PublicKey:
+		if alg != jwt.EdDSA {
+			return nil, jwt.ErrUnsupportedAlg
+		}
+		return jwt.NewVerifierEdDSA(k)
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+func readPEMBlock(path string) (*pem.Block, error) {
+	if path == "" {
+		return nil, errors.New("path to PEM file is empty")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PEM file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	return block, nil
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key from %s: %w", path, err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key from %s: %w", path, err)
+		}
+		return key, nil
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate from %s: %w", path, err)
+		}
+		return cert.PublicKey, nil
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key from %s: %w", path, err)
+		}
+		return key, nil
+	}
+}
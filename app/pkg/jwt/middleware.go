@@ -3,63 +3,83 @@ package jwt
 import (
 	"context"
 	"encoding/json"
-	"finance-manager-api-service/internal/config"
+	"errors"
+	"finance-manager-api-service/internal/apperror"
 	"finance-manager-api-service/pkg/logging"
-	"github.com/cristalhq/jwt/v3"
 	"net/http"
 	"strings"
 	"time"
 )
 
+var (
+	errMalformedToken = errors.New("malformed token")
+	errTokenExpired   = errors.New("token has been expired")
+	errTokenRevoked   = errors.New("token has been revoked")
+)
+
 func Middleware(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger := logging.GetLogger()
-		authHeader := strings.Split(r.Header.Get("Authorization"), "Bearer ")
-
-		if len(authHeader) != 2 {
-			logger.Error("Malformed token")
-			w.WriteHeader(http.StatusUnauthorized)
-			_, _ = w.Write([]byte("malformed token"))
-			return
-		}
 
-		logger.Debug("create jwt verifier")
-		jwtToken := authHeader[1]
-		key := []byte(config.GetConfig().JWT.Secret)
-		verifier, err := jwt.NewVerifierHS(jwt.HS256, key)
+		uc, err := ClaimsFromRequest(r)
 		if err != nil {
-			unauthorized(w, err)
+			if errors.Is(err, errMalformedToken) {
+				logger.Error("Malformed token")
+				apperror.Render(w, r, apperror.UnauthorizedError("malformed token"))
+				return
+			}
+			unauthorized(w, r, err)
 			return
 		}
 
-		logger.Debug("parse and verify jwt token")
-		token, err := jwt.ParseAndVerifyString(jwtToken, verifier)
-		if err != nil {
-			unauthorized(w, err)
-			return
+		if revocations != nil {
+			logger.Debug("check token revocation")
+			revoked, err := revocations.IsRevoked(uc)
+			if err != nil {
+				unauthorized(w, r, err)
+				return
+			}
+			if revoked {
+				unauthorized(w, r, errTokenRevoked)
+				return
+			}
 		}
 
-		logger.Debug("parse user claims")
-		var uc UserClaims
-		err = json.Unmarshal(token.RawClaims(), &uc)
-		if err != nil {
-			unauthorized(w, err)
-			return
-		}
+		ctx := context.WithValue(r.Context(), "user_uuid", uc.UserUUID())
+		h(w, r.WithContext(ctx))
+	}
+}
 
-		if valid := uc.IsValidAt(time.Now()); !valid {
-			logger.Error("token has been expired")
-			unauthorized(w, err)
-			return
-		}
+// ClaimsFromRequest verifies bearer token from Authorization header and returns its claims.
+// Revocation is not checked here.
+func ClaimsFromRequest(r *http.Request) (UserClaims, error) {
+	logger := logging.GetLogger()
+	var uc UserClaims
 
-		ctx := context.WithValue(r.Context(), "user_uuid", uc.ID)
-		h(w, r.WithContext(ctx))
+	authHeader := strings.Split(r.Header.Get("Authorization"), "Bearer ")
+	if len(authHeader) != 2 {
+		return uc, errMalformedToken
+	}
+
+	logger.Debug("parse and verify jwt token")
+	jwtToken := authHeader[1]
+	token, err := GetKeySet().ParseAndVerify(jwtToken)
+	if err != nil {
+		return uc, err
+	}
+
+	logger.Debug("parse user claims")
+	if err = json.Unmarshal(token.RawClaims(), &uc); err != nil {
+		return uc, err
+	}
+
+	if valid := uc.IsValidAt(time.Now()); !valid {
+		return uc, errTokenExpired
 	}
+	return uc, nil
 }
 
-func unauthorized(w http.ResponseWriter, err error) {
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
 	logging.GetLogger().Error(err)
-	w.WriteHeader(http.StatusUnauthorized)
-	_, _ = w.Write([]byte("unauthorized"))
+	apperror.Render(w, r, apperror.UnauthorizedError("unauthorized"))
 }
@@ -0,0 +1,304 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/pkg/cache"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	familyKeyPrefix = "rt_family:"
+	userKeyPrefix   = "rt_user:"
+	// index of user's families in stores implementing cache.ExpiringSet
+	userSetKeyPrefix = "rt_user_set:"
+	// marks refresh token as used, it is set once, so only one refresh with the token succeeds
+	usedKeyPrefix = "rt_used:"
+	// marks revoked family, unlike the family record it is not overwritten by a concurrent refresh
+	revokedKeyPrefix = "rt_revoked:"
+)
+
+var errInvalidRefreshToken = apperror.UnauthorizedError("refresh token is invalid or expired")
+
+// tokenFamily is a chain of refresh tokens that started from one login. Every refresh
+// rotates the token, and presenting an already rotated token revokes the whole chain.
+type tokenFamily struct {
+	ID           string    `json:"id"`
+	Session      Session   `json:"session"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CurrentToken string    `json:"current_token"`
+	Revoked      bool      `json:"revoked"`
+}
+
+// userFamilies indexes token families by user, so all sessions of the user can be revoked
+type userFamilies struct {
+	Families map[string]time.Time `json:"families"`
+}
+
+type refreshTokenEntry struct {
+	FamilyID string `json:"family_id"`
+}
+
+// saveRefreshToken creates new refresh token in family and makes it the current one
+func (h helper) saveRefreshToken(family tokenFamily) (string, error) {
+	familyTTL := secondsUntil(family.ExpiresAt)
+	if familyTTL <= 0 {
+		return "", errInvalidRefreshToken
+	}
+	tokenTTL := min(familyTTL, int(h.idleTTL.Seconds()))
+
+	refreshToken := uuid.New().String()
+	entry := refreshTokenEntry{
+		FamilyID: family.ID,
+	}
+	if err := h.setJSON(refreshToken, entry, tokenTTL); err != nil {
+		return "", err
+	}
+
+	isNewFamily := family.CurrentToken == ""
+	family.CurrentToken = refreshToken
+	if err := h.setJSON(familyKeyPrefix+family.ID, family, familyTTL); err != nil {
+		return "", err
+	}
+
+	if isNewFamily {
+		if err := h.indexFamily(family); err != nil {
+			return "", err
+		}
+	}
+	return refreshToken, nil
+}
+
+// indexFamily adds family to user's index and drops expired families from it. Stores shared by
+// several instances implement cache.ExpiringSet and update the index atomically, the index in
+// other stores is a JSON record guarded by indexMu.
+func (h helper) indexFamily(family tokenFamily) error {
+	if set, ok := h.RTCache.(cache.ExpiringSet); ok {
+		return set.AddMember([]byte(userSetKeyPrefix+family.Session.UserUUID), []byte(family.ID), family.ExpiresAt)
+	}
+
+	h.indexMu.Lock()
+	defer h.indexMu.Unlock()
+
+	var index userFamilies
+	if err := h.getJSON(userKeyPrefix+family.Session.UserUUID, &index); err != nil && !errors.Is(err, errInvalidRefreshToken) {
+		return err
+	}
+	if index.Families == nil {
+		index.Families = make(map[string]time.Time)
+	}
+
+	now := time.Now()
+	index.Families[family.ID] = family.ExpiresAt
+	latest := family.ExpiresAt
+	for id, expiresAt := range index.Families {
+		if !expiresAt.After(now) {
+			delete(index.Families, id)
+			continue
+		}
+		if expiresAt.After(latest) {
+			latest = expiresAt
+		}
+	}
+
+	return h.setJSON(userKeyPrefix+family.Session.UserUUID, index, secondsUntil(latest))
+}
+
+// RevokeRefreshToken revokes family of the presented refresh token. Unknown or expired tokens are ignored.
+func (h helper) RevokeRefreshToken(rt RefreshToken) error {
+	_, family, err := h.getRefreshToken(rt.RefreshToken)
+	if err != nil {
+		if errors.Is(err, errInvalidRefreshToken) {
+			return nil
+		}
+		return err
+	}
+
+	h.logger.Infof("revoke refresh token family %s of user %s", family.ID, family.Session.UserUUID)
+	return h.revokeFamily(family)
+}
+
+// RevokeAllRefreshTokens revokes every token family of the user
+func (h helper) RevokeAllRefreshTokens(userUUID string) error {
+	set, ok := h.RTCache.(cache.ExpiringSet)
+	if !ok {
+		h.indexMu.Lock()
+		defer h.indexMu.Unlock()
+	}
+
+	// with cache.ExpiringSet the JSON record holds families indexed before the store got the set
+	var index userFamilies
+	if err := h.getJSON(userKeyPrefix+userUUID, &index); err != nil && !errors.Is(err, errInvalidRefreshToken) {
+		return err
+	}
+	familyIDs := make([]string, 0, len(index.Families))
+	for familyID := range index.Families {
+		familyIDs = append(familyIDs, familyID)
+	}
+	if ok {
+		members, err := set.Members([]byte(userSetKeyPrefix + userUUID))
+		if err != nil {
+			return fmt.Errorf("failed to get refresh token families: %w", err)
+		}
+		for _, member := range members {
+			familyIDs = append(familyIDs, string(member))
+		}
+	}
+
+	h.logger.Infof("revoke %d refresh token families of user %s", len(familyIDs), userUUID)
+	for _, familyID := range familyIDs {
+		if err := h.revokeFamilyByID(familyID); err != nil {
+			return err
+		}
+		// families added by concurrent logins stay in the set
+		if ok {
+			if err := set.RemoveMember([]byte(userSetKeyPrefix+userUUID), []byte(familyID)); err != nil {
+				return fmt.Errorf("failed to remove refresh token family from index: %w", err)
+			}
+		}
+	}
+
+	h.RTCache.Del([]byte(userKeyPrefix + userUUID))
+	return nil
+}
+
+func (h helper) revokeFamilyByID(familyID string) error {
+	var family tokenFamily
+	if err := h.getJSON(familyKeyPrefix+familyID, &family); err != nil {
+		if errors.Is(err, errInvalidRefreshToken) {
+			return nil
+		}
+		return err
+	}
+	return h.revokeFamily(family)
+}
+
+func (h helper) getRefreshToken(refreshToken string) (refreshTokenEntry, tokenFamily, error) {
+	var entry refreshTokenEntry
+	var family tokenFamily
+
+	// refresh tokens are uuids, anything else may point to service records in the same store
+	if _, err := uuid.Parse(refreshToken); err != nil {
+		return entry, family, errInvalidRefreshToken
+	}
+	if err := h.getJSON(refreshToken, &entry); err != nil {
+		return entry, family, err
+	}
+	if err := h.getJSON(familyKeyPrefix+entry.FamilyID, &family); err != nil {
+		return entry, family, err
+	}
+	return entry, family, nil
+}
+
+// useRefreshToken validates refresh token and marks it as used. Used marks are kept until
+// their family expires, so replaying the token is detected and revokes the family. The mark
+// is claimed atomically: of concurrent refreshes with the same token only one succeeds,
+// others are treated as reuse.
+func (h helper) useRefreshToken(refreshToken string) (tokenFamily, error) {
+	_, family, err := h.getRefreshToken(refreshToken)
+	if err != nil {
+		return family, err
+	}
+
+	revoked, err := h.isRevoked(family)
+	if err != nil {
+		return family, err
+	}
+	if revoked {
+		h.logger.Warnf("refresh token of revoked family %s was presented for user %s",
+			family.ID, family.Session.UserUUID)
+		return family, errInvalidRefreshToken
+	}
+
+	if family.CurrentToken != refreshToken {
+		return family, h.reuseDetected(family)
+	}
+
+	familyTTL := secondsUntil(family.ExpiresAt)
+	if familyTTL <= 0 {
+		return family, errInvalidRefreshToken
+	}
+
+	claimed, err := h.RTCache.SetNX([]byte(usedKeyPrefix+refreshToken), []byte{1}, familyTTL)
+	if err != nil {
+		return family, fmt.Errorf("failed to mark refresh token as used: %w", err)
+	}
+	if !claimed {
+		return family, h.reuseDetected(family)
+	}
+	return family, nil
+}
+
+func (h helper) isRevoked(family tokenFamily) (bool, error) {
+	if family.Revoked {
+		return true, nil
+	}
+	_, err := h.RTCache.Get([]byte(revokedKeyPrefix + family.ID))
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (h helper) reuseDetected(family tokenFamily) error {
+	h.logger.Warnf("refresh token reuse detected for user %s, revoking token family %s",
+		family.Session.UserUUID, family.ID)
+	if err := h.revokeFamily(family); err != nil {
+		h.logger.Error(err)
+	}
+	return errInvalidRefreshToken
+}
+
+// revokeFamily deletes current refresh token of the family and remembers that family is revoked
+func (h helper) revokeFamily(family tokenFamily) error {
+	if family.CurrentToken != "" {
+		h.RTCache.Del([]byte(family.CurrentToken))
+	}
+
+	family.Revoked = true
+	family.CurrentToken = ""
+	ttl := secondsUntil(family.ExpiresAt)
+	if ttl <= 0 {
+		h.RTCache.Del([]byte(familyKeyPrefix + family.ID))
+		return nil
+	}
+	if err := h.RTCache.Set([]byte(revokedKeyPrefix+family.ID), []byte{1}, ttl); err != nil {
+		return fmt.Errorf("failed to save %s: %w", revokedKeyPrefix+family.ID, err)
+	}
+	return h.setJSON(familyKeyPrefix+family.ID, family, ttl)
+}
+
+func (h helper) setJSON(key string, value any, expireSeconds int) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", key, err)
+	}
+	if err = h.RTCache.Set([]byte(key), valueBytes, expireSeconds); err != nil {
+		return fmt.Errorf("failed to save %s: %w", key, err)
+	}
+	return nil
+}
+
+func (h helper) getJSON(key string, value any) error {
+	valueBytes, err := h.RTCache.Get([]byte(key))
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return errInvalidRefreshToken
+		}
+		return err
+	}
+	if err = json.Unmarshal(valueBytes, value); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", key, err)
+	}
+	return nil
+}
+
+func secondsUntil(t time.Time) int {
+	return int(time.Until(t).Seconds())
+}
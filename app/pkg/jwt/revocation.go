@@ -0,0 +1,96 @@
+package jwt
+
+import (
+	"errors"
+	"finance-manager-api-service/pkg/cache"
+	"finance-manager-api-service/pkg/logging"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	revokedTokenKeyPrefix = "at_revoked:"
+	notBeforeKeyPrefix    = "at_not_before:"
+)
+
+// RevocationList makes access tokens invalid before they expire. Single token is revoked by its
+// "jti", all tokens of the user are revoked by watermark: tokens issued before it are rejected.
+// Entries live no longer than access token itself, after that the token is rejected as expired.
+type RevocationList interface {
+	RevokeToken(tokenID string, expiresAt time.Time) error
+	RevokeIssuedBefore(userUUID string, before time.Time) error
+	IsRevoked(claims UserClaims) (bool, error)
+}
+
+type revocationList struct {
+	store  cache.Repository
+	logger *logging.Logger
+}
+
+func NewRevocationList(store cache.Repository, logger *logging.Logger) RevocationList {
+	return &revocationList{
+		store:  store,
+		logger: logger,
+	}
+}
+
+var revocations RevocationList
+
+// SetRevocationList makes Middleware reject revoked access tokens
+func SetRevocationList(rl RevocationList) {
+	revocations = rl
+}
+
+func (rl *revocationList) RevokeToken(tokenID string, expiresAt time.Time) error {
+	ttl := secondsUntil(expiresAt)
+	if tokenID == "" || ttl <= 0 {
+		return nil
+	}
+
+	rl.logger.Debugf("revoke access token %s", tokenID)
+	if err := rl.store.Set([]byte(revokedTokenKeyPrefix+tokenID), []byte{1}, ttl); err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	return nil
+}
+
+func (rl *revocationList) RevokeIssuedBefore(userUUID string, before time.Time) error {
+	rl.logger.Debugf("revoke access tokens of user %s issued before %s", userUUID, before)
+
+	watermark := []byte(strconv.FormatInt(before.Unix(), 10))
+	if err := rl.store.Set([]byte(notBeforeKeyPrefix+userUUID), watermark, int(accessTokenTTL.Seconds())); err != nil {
+		return fmt.Errorf("failed to revoke access tokens of user: %w", err)
+	}
+	return nil
+}
+
+func (rl *revocationList) IsRevoked(claims UserClaims) (bool, error) {
+	if claims.ID != "" {
+		_, err := rl.store.Get([]byte(revokedTokenKeyPrefix + claims.ID))
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, cache.ErrNotFound) {
+			return false, err
+		}
+	}
+
+	watermark, err := rl.store.Get([]byte(notBeforeKeyPrefix + claims.UserUUID()))
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	notBefore, err := strconv.ParseInt(string(watermark), 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("malformed revocation watermark: %w", err)
+	}
+
+	// tokens issued before watermarks were introduced have no "iat" and are rejected too
+	if claims.IssuedAt == nil {
+		return true, nil
+	}
+	return claims.IssuedAt.Unix() < notBefore, nil
+}
@@ -0,0 +1,31 @@
+package jwt
+
+import (
+	"finance-manager-api-service/pkg/utils"
+	"net/http"
+	"time"
+)
+
+// Session is stored with refresh token family instead of the user itself,
+// so no credentials are ever kept in the refresh token store
+type Session struct {
+	UserUUID string    `json:"user_uuid"`
+	Email    string    `json:"email"`
+	IssuedAt time.Time `json:"issued_at"`
+	// nil until the session is refreshed for the first time
+	RefreshedAt *time.Time `json:"refreshed_at,omitempty"`
+	Client      Client     `json:"client"`
+}
+
+// Client describes device that the session was created or last refreshed from
+type Client struct {
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+func NewClient(r *http.Request) Client {
+	return Client{
+		IP:        utils.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+}
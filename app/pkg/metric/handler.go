@@ -1,28 +1,53 @@
 package metric
 
 import (
+	"encoding/json"
 	h "finance-manager-api-service/internal/handler"
+	"finance-manager-api-service/pkg/breaker"
+	"finance-manager-api-service/pkg/health"
 	"finance-manager-api-service/pkg/logging"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
+	"time"
 )
 
 const (
-	URL = "/api/heartbeat"
+	URL         = "/api/heartbeat"
+	liveURL     = "/api/health/live"
+	readyURL    = "/api/health/ready"
+	breakersURL = "/api/health/breakers"
+	metricsURL  = "/metrics"
 )
 
 type handler struct {
-	Logger *logging.Logger
+	Logger    *logging.Logger
+	Readiness *health.Probe
+	Metrics   *Metrics
+	Breakers  []*breaker.Breaker
 }
 
-func NewHandler(logger *logging.Logger) h.Handler {
-	return &handler{
-		Logger: logger,
+// NewHandler skips nil breakers, they belong to upstreams with disabled circuit breaker
+func NewHandler(logger *logging.Logger, readiness *health.Probe, metrics *Metrics,
+	breakers ...*breaker.Breaker) h.Handler {
+	metricHandler := &handler{
+		Logger:    logger,
+		Readiness: readiness,
+		Metrics:   metrics,
 	}
+	for _, b := range breakers {
+		if b != nil {
+			metricHandler.Breakers = append(metricHandler.Breakers, b)
+		}
+	}
+	return metricHandler
 }
 
 func (h *handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodGet, URL, h.Heartbeat)
+	router.HandlerFunc(http.MethodGet, liveURL, h.Live)
+	router.HandlerFunc(http.MethodGet, readyURL, h.Ready)
+	router.HandlerFunc(http.MethodGet, breakersURL, h.GetBreakers)
+	router.Handler(http.MethodGet, metricsURL, h.Metrics.Handler())
 }
 
 // Heartbeat
@@ -34,3 +59,65 @@ func (h *handler) Register(router *httprouter.Router) {
 func (h *handler) Heartbeat(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(204)
 }
+
+// Live
+// @Summary 	Liveness
+// @Description Checks that the process is up, dependencies are not checked
+// @Tags 		Heartbeat
+// @Produce 	json
+// @Success 	200 {object} health.Report "Application is alive"
+// @Router 		/health/live [get]
+func (h *handler) Live(w http.ResponseWriter, req *http.Request) {
+	h.writeJSON(w, http.StatusOK, health.Report{
+		Status:       health.StatusUp,
+		CheckedAt:    time.Now(),
+		Dependencies: []health.DependencyStatus{},
+	})
+}
+
+// Ready
+// @Summary 	Readiness
+// @Description Checks user, operation and stats services, the result is cached for a few seconds
+// @Tags 		Heartbeat
+// @Produce 	json
+// @Success 	200 {object} health.Report "All dependencies are up"
+// @Failure 	503 {object} health.Report "Some dependencies are down"
+// @Router 		/health/ready [get]
+func (h *handler) Ready(w http.ResponseWriter, req *http.Request) {
+	report := h.Readiness.Check(req.Context())
+	status := http.StatusOK
+	if report.Status != health.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	h.writeJSON(w, status, report)
+}
+
+// GetBreakers
+// @Summary 	Circuit breakers
+// @Description Returns state of circuit breakers of downstream services: closed, open or half-open
+// @Tags 		Heartbeat
+// @Produce 	json
+// @Success 	200 {object} []breaker.Snapshot "Circuit breakers"
+// @Router 		/health/breakers [get]
+func (h *handler) GetBreakers(w http.ResponseWriter, req *http.Request) {
+	snapshots := make([]breaker.Snapshot, len(h.Breakers))
+	for i, b := range h.Breakers {
+		snapshots[i] = b.Snapshot()
+	}
+
+	h.writeJSON(w, http.StatusOK, snapshots)
+}
+
+func (h *handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		h.Logger.Errorf("failed to marshal response: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,211 @@
+package metric
+
+import (
+	"finance-manager-api-service/pkg/breaker"
+	"finance-manager-api-service/pkg/cache"
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unmatchedRoute labels requests that do not match any route, so unknown paths do not create new series
+const unmatchedRoute = "unmatched"
+
+// Metrics holds collectors exposed in Prometheus format: Go runtime and process stats, requests
+// to the API, calls to upstream services, circuit breakers and caches
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requestDuration    *prometheus.HistogramVec
+	upstreamDuration   *prometheus.HistogramVec
+	upstreamErrors     *prometheus.CounterVec
+	upstreamRetries    *prometheus.CounterVec
+	breakerTransitions *prometheus.CounterVec
+	breakerRejections  *prometheus.CounterVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of HTTP requests to the API by route and status, count of the histogram is number of requests.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "upstream_request_duration_seconds",
+			Help:    "Duration of single attempts of calls to upstream services by HTTP status or gRPC code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"service", "method", "code"}),
+		upstreamErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "upstream_errors_total",
+			Help: "Attempts of calls to upstream services failed with network error or server error.",
+		}, []string{"service", "method", "code"}),
+		upstreamRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "upstream_retries_total",
+			Help: "Retries of calls to upstream services.",
+		}, []string{"service", "method"}),
+		breakerTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "circuit_breaker_transitions_total",
+			Help: "State changes of circuit breakers.",
+		}, []string{"service", "from", "to"}),
+		breakerRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "circuit_breaker_rejections_total",
+			Help: "Calls rejected by open circuit breakers.",
+		}, []string{"service"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.upstreamDuration,
+		m.upstreamErrors,
+		m.upstreamRetries,
+		m.breakerTransitions,
+		m.breakerRejections,
+	)
+	return m
+}
+
+// Handler serves metrics in Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware observes requests served by router. Requests are labeled with route pattern
+// like /api/operations/:uuid, not with the path, so the number of series stays bounded.
+func (m *Metrics) Middleware(router *httprouter.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		router.ServeHTTP(sw, r)
+
+		method, route := r.Method, routeOf(router, r)
+		if route == unmatchedRoute {
+			method = ""
+		}
+		m.requestDuration.WithLabelValues(method, route, strconv.Itoa(sw.Status())).
+			Observe(time.Since(start).Seconds())
+	})
+}
+
+// routeOf restores the pattern of route matched by request replacing values of parameters with their names
+func routeOf(router *httprouter.Router, r *http.Request) string {
+	handle, params, _ := router.Lookup(r.Method, r.URL.Path)
+	if handle == nil {
+		return unmatchedRoute
+	}
+
+	route := r.URL.Path
+	// value of catch-all parameter is the rest of the path starting with slash
+	if n := len(params); n > 0 && strings.HasPrefix(params[n-1].Value, "/") {
+		route = strings.TrimSuffix(route, params[n-1].Value) + "/*" + params[n-1].Key
+		params = params[:n-1]
+	}
+
+	segments := strings.Split(route, "/")
+	for i := range segments {
+		if len(params) > 0 && segments[i] == params[0].Value {
+			segments[i] = ":" + params[0].Key
+			params = params[1:]
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// BreakerStateChanged counts state changes, it fits breaker.Config.OnStateChange
+func (m *Metrics) BreakerStateChanged(name string, from, to breaker.State) {
+	m.breakerTransitions.WithLabelValues(name, from.String(), to.String()).Inc()
+}
+
+// BreakerRejected counts calls rejected by open breaker, it fits breaker.Config.OnReject
+func (m *Metrics) BreakerRejected(name string) {
+	m.breakerRejections.WithLabelValues(name).Inc()
+}
+
+// RegisterBreakers exposes current state of breakers: 0 is closed, 1 is open and 2 is half-open.
+// Nil breakers are skipped.
+func (m *Metrics) RegisterBreakers(breakers ...*breaker.Breaker) {
+	for _, b := range breakers {
+		if b == nil {
+			continue
+		}
+		m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "circuit_breaker_state",
+			Help:        "State of circuit breaker: 0 is closed, 1 is open, 2 is half-open.",
+			ConstLabels: prometheus.Labels{"service": b.Name()},
+		}, func() float64 {
+			return float64(b.State())
+		}))
+	}
+}
+
+// RegisterCache exposes hits and misses of the cache and number of its entries when the store counts
+// them. Counts are read on every scrape.
+func (m *Metrics) RegisterCache(name string, repository cache.Repository) {
+	labels := prometheus.Labels{"cache": name}
+	m.registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "cache_hits_total",
+			Help:        "Lookups that found entry in the cache.",
+			ConstLabels: labels,
+		}, func() float64 {
+			return float64(repository.HitCount())
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "cache_misses_total",
+			Help:        "Lookups that did not find entry in the cache.",
+			ConstLabels: labels,
+		}, func() float64 {
+			return float64(repository.MissCount())
+		}),
+	)
+	if repository.EntryCount() < 0 {
+		return
+	}
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "cache_entries",
+		Help:        "Number of entries in the cache.",
+		ConstLabels: labels,
+	}, func() float64 {
+		return float64(repository.EntryCount())
+	}))
+}
+
+// statusWriter remembers status of the response, status is 200 when handler writes body without header
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Unwrap lets http.ResponseController reach the original writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,71 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// Upstream observes calls to one upstream service. Nil Upstream ignores observations.
+type Upstream struct {
+	duration prometheus.ObserverVec
+	errors   *prometheus.CounterVec
+	retries  *prometheus.CounterVec
+}
+
+func (m *Metrics) Upstream(service string) *Upstream {
+	labels := prometheus.Labels{"service": service}
+	return &Upstream{
+		duration: m.upstreamDuration.MustCurryWith(labels),
+		errors:   m.upstreamErrors.MustCurryWith(labels),
+		retries:  m.upstreamRetries.MustCurryWith(labels),
+	}
+}
+
+// ObserveCall records single attempt of the call. Method is bounded name of the call like
+// "GET /operations/one/:id", code is HTTP status, gRPC code or "error" when there is no response.
+func (u *Upstream) ObserveCall(method, code string, failed bool, duration time.Duration) {
+	if u == nil {
+		return
+	}
+	u.duration.WithLabelValues(method, code).Observe(duration.Seconds())
+	if failed {
+		u.errors.WithLabelValues(method, code).Inc()
+	}
+}
+
+func (u *Upstream) ObserveRetry(method string) {
+	if u == nil {
+		return
+	}
+	u.retries.WithLabelValues(method).Inc()
+}
+
+// UnaryClientInterceptor observes every attempt of the call, it goes after the retry interceptor
+// in the chain. Calls failed with Unavailable, DeadlineExceeded, Internal or Unknown code count
+// as errors of the upstream unless they were canceled by the caller. Health checks are not observed.
+func (u *Upstream) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if method == grpc_health_v1.Health_Check_FullMethodName {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		code := status.Code(err)
+		var failed bool
+		switch code {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+			failed = !errors.Is(ctx.Err(), context.Canceled)
+		}
+		u.ObserveCall(method, code.String(), failed, time.Since(start))
+		return err
+	}
+}
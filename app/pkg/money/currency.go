@@ -0,0 +1,14 @@
+package money
+
+// IsCurrencyCode checks that code looks like ISO 4217 alphabetic code: three upper case letters
+func IsCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
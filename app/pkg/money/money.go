@@ -0,0 +1,206 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale is number of fractional digits kept by Amount
+const Scale = 4
+
+const (
+	unitsPerOne = 10000
+	// exponents are limited as big.Rat allocates memory proportional to them
+	maxExponent = 30
+)
+
+var errOutOfRange = errors.New("amount is out of range")
+
+var encodeAsString bool
+
+// EncodeAsString makes amounts encoded to JSON as strings like "12.50" instead of number literals,
+// for clients that parse JSON numbers as float64. Amounts are decoded from both forms either way.
+// It is set once at start.
+func EncodeAsString(enabled bool) {
+	encodeAsString = enabled
+}
+
+// Amount is fixed-point decimal amount of money. It is encoded to JSON as number literal, or as
+// string when EncodeAsString is set, and decoded from either number or string, so values are never
+// rounded through float64.
+type Amount struct {
+	units int64
+}
+
+// FromUnits creates amount from number of 1/10^Scale fractions
+func FromUnits(units int64) Amount {
+	return Amount{units: units}
+}
+
+// Parse reads decimal number like "-12.5" or "1e3", digits beyond Scale are rounded half away from zero
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if !isDecimal(s) {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+
+	return fromRat(r)
+}
+
+// fromRat rounds r half away from zero to Scale fractional digits
+func fromRat(r *big.Rat) (Amount, error) {
+	scaled := new(big.Rat).Mul(r, big.NewRat(unitsPerOne, 1))
+	units, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if new(big.Int).Lsh(rem.Abs(rem), 1).Cmp(scaled.Denom()) >= 0 {
+		units.Add(units, big.NewInt(int64(scaled.Sign())))
+	}
+	if !units.IsInt64() {
+		return Amount{}, errOutOfRange
+	}
+	return Amount{units: units.Int64()}, nil
+}
+
+// isDecimal allows only plain decimal notation, big.Rat also accepts fractions and hex numbers
+func isDecimal(s string) bool {
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(s), "e")
+	if hasExponent {
+		exp, err := strconv.Atoi(exponent)
+		if err != nil || exp < -maxExponent || exp > maxExponent {
+			return false
+		}
+	}
+	if strings.HasPrefix(mantissa, "-") || strings.HasPrefix(mantissa, "+") {
+		mantissa = mantissa[1:]
+	}
+	return mantissa != "" && mantissa != "." && strings.Trim(mantissa, "0123456789.") == "" &&
+		strings.Count(mantissa, ".") <= 1
+}
+
+// Sum adds amounts up exactly
+func Sum(amounts ...Amount) Amount {
+	var total Amount
+	for _, a := range amounts {
+		total = total.Add(a)
+	}
+	return total
+}
+
+func (a Amount) Units() int64 {
+	return a.units
+}
+
+func (a Amount) Add(b Amount) Amount {
+	return Amount{units: a.units + b.units}
+}
+
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{units: a.units - b.units}
+}
+
+// Mul multiplies amount by exact factor like exchange rate, result is rounded to Scale digits
+func (a Amount) Mul(factor *big.Rat) (Amount, error) {
+	return fromRat(new(big.Rat).Mul(a.rat(), factor))
+}
+
+func (a Amount) rat() *big.Rat {
+	return big.NewRat(a.units, unitsPerOne)
+}
+
+func (a Amount) Neg() Amount {
+	return Amount{units: -a.units}
+}
+
+func (a Amount) Sign() int {
+	switch {
+	case a.units > 0:
+		return 1
+	case a.units < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func (a Amount) IsZero() bool {
+	return a.units == 0
+}
+
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a.units > b.units:
+		return 1
+	case a.units < b.units:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// Float64 is for comparisons and display only, arithmetic must be done with Amount
+func (a Amount) Float64() float64 {
+	return float64(a.units) / unitsPerOne
+}
+
+// String formats amount with at least two fractional digits, like "12.50" or "-0.0125"
+func (a Amount) String() string {
+	sign := ""
+	units := uint64(a.units)
+	if a.units < 0 {
+		sign = "-"
+		units = uint64(-a.units)
+		if a.units == math.MinInt64 {
+			units = uint64(math.MaxInt64) + 1
+		}
+	}
+
+	fraction := fmt.Sprintf("%0*d", Scale, units%unitsPerOne)
+	fraction = strings.TrimRight(fraction, "0")
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+	return sign + strconv.FormatUint(units/unitsPerOne, 10) + "." + fraction
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	if encodeAsString {
+		return []byte(`"` + a.String() + `"`), nil
+	}
+	return []byte(a.String()), nil
+}
+
+// Number is amount as JSON number literal whatever encoding is chosen by EncodeAsString,
+// for payloads with fixed format like requests to other services
+func (a Amount) Number() json.Number {
+	return json.Number(a.String())
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		data = []byte(s)
+	}
+
+	parsed, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
@@ -0,0 +1,187 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		units   int64
+		invalid bool
+	}{
+		{in: "12.5", units: 125000},
+		{in: "-0.01", units: -100},
+		{in: "+3", units: 30000},
+		{in: " 7.25 ", units: 72500},
+		{in: ".5", units: 5000},
+		{in: "1e3", units: 10000000},
+		{in: "25E-1", units: 25000},
+		{in: "0.00005", units: 1},
+		{in: "0.00004", units: 0},
+		{in: "-0.00005", units: -1},
+		{in: "1.23455", units: 12346},
+		{in: "922337203685477.5807", units: math.MaxInt64},
+		{in: "922337203685477.5808", invalid: true},
+		{in: "1e31", invalid: true},
+		{in: "1/3", invalid: true},
+		{in: "0x10", invalid: true},
+		{in: "1.2.3", invalid: true},
+		{in: ".", invalid: true},
+		{in: "-", invalid: true},
+		{in: "", invalid: true},
+		{in: "NaN", invalid: true},
+		{in: "Inf", invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if tt.invalid {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %v, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.in, err)
+			}
+			if got.Units() != tt.units {
+				t.Errorf("Parse(%q) = %d units, want %d", tt.in, got.Units(), tt.units)
+			}
+		})
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a, b := FromUnits(1000), FromUnits(2000) // 0.1 and 0.2 are not exact in float64
+	if got := a.Add(b); got != FromUnits(3000) {
+		t.Errorf("0.1 + 0.2 = %s, want 0.30", got)
+	}
+	if got := a.Sub(b); got != FromUnits(-1000) {
+		t.Errorf("0.1 - 0.2 = %s, want -0.10", got)
+	}
+	if got := Sum(a, b, a.Neg()); got != b {
+		t.Errorf("Sum = %s, want %s", got, b)
+	}
+	if got := a.Neg(); got.Units() != -1000 || got.Sign() != -1 || got.Neg() != a {
+		t.Errorf("Neg(0.1) = %s", got)
+	}
+	if !FromUnits(0).IsZero() || FromUnits(0).Sign() != 0 || a.Sign() != 1 {
+		t.Error("unexpected sign of zero or positive amount")
+	}
+	if a.Cmp(b) != -1 || b.Cmp(a) != 1 || a.Cmp(a) != 0 {
+		t.Error("unexpected comparison of 0.1 and 0.2")
+	}
+}
+
+func TestMulRounding(t *testing.T) {
+	tests := []struct {
+		name   string
+		units  int64
+		factor *big.Rat
+		want   int64
+	}{
+		{name: "exact", units: 100000, factor: big.NewRat(92, 100), want: 92000},
+		{name: "half up", units: 1, factor: big.NewRat(1, 2), want: 1},
+		{name: "below half", units: 1, factor: big.NewRat(4, 10), want: 0},
+		{name: "negative half away from zero", units: -1, factor: big.NewRat(1, 2), want: -1},
+		{name: "third", units: 10000, factor: big.NewRat(1, 3), want: 3333},
+		{name: "two thirds", units: 10000, factor: big.NewRat(2, 3), want: 6667},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromUnits(tt.units).Mul(tt.factor)
+			if err != nil {
+				t.Fatalf("Mul() error = %v", err)
+			}
+			if got.Units() != tt.want {
+				t.Errorf("Mul() = %d units, want %d", got.Units(), tt.want)
+			}
+		})
+	}
+
+	if _, err := FromUnits(math.MaxInt64).Mul(big.NewRat(2, 1)); err == nil {
+		t.Error("Mul() overflow error = nil")
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		units int64
+		want  string
+	}{
+		{units: 0, want: "0.00"},
+		{units: 125000, want: "12.50"},
+		{units: -125, want: "-0.0125"},
+		{units: 1, want: "0.0001"},
+		{units: math.MinInt64, want: "-922337203685477.5808"},
+	}
+	for _, tt := range tests {
+		if got := FromUnits(tt.units).String(); got != tt.want {
+			t.Errorf("String(%d units) = %q, want %q", tt.units, got, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type payload struct {
+		Sum Amount `json:"sum"`
+	}
+	tests := []struct {
+		name       string
+		asString   bool
+		in         string
+		units      int64
+		wantEncode string
+	}{
+		{name: "number", in: `{"sum": 12.5}`, units: 125000, wantEncode: `{"sum":12.50}`},
+		{name: "string", in: `{"sum": "12.5"}`, units: 125000, wantEncode: `{"sum":12.50}`},
+		{name: "number not rounded through float", in: `{"sum": 9007199254740.9993}`, units: 90071992547409993,
+			wantEncode: `{"sum":9007199254740.9993}`},
+		{name: "null", in: `{"sum": null}`, units: 0, wantEncode: `{"sum":0.00}`},
+		{name: "encoded as string", asString: true, in: `{"sum": -0.0125}`, units: -125,
+			wantEncode: `{"sum":"-0.0125"}`},
+		{name: "string encoded as string", asString: true, in: `{"sum": "3"}`, units: 30000,
+			wantEncode: `{"sum":"3.00"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			EncodeAsString(tt.asString)
+			defer EncodeAsString(false)
+
+			var p payload
+			if err := json.Unmarshal([]byte(tt.in), &p); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if p.Sum.Units() != tt.units {
+				t.Errorf("decoded %d units, want %d", p.Sum.Units(), tt.units)
+			}
+
+			encoded, err := json.Marshal(p)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(encoded) != tt.wantEncode {
+				t.Errorf("Marshal() = %s, want %s", encoded, tt.wantEncode)
+			}
+
+			var decoded payload
+			if err = json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatalf("Unmarshal() of encoded error = %v", err)
+			}
+			if decoded != p {
+				t.Errorf("round trip = %s, want %s", decoded.Sum, p.Sum)
+			}
+		})
+	}
+
+	for _, in := range []string{`{"sum": "abc"}`, `{"sum": true}`, `{"sum": "1e40"}`} {
+		var p payload
+		if err := json.Unmarshal([]byte(in), &p); err == nil {
+			t.Errorf("Unmarshal(%s) error = nil", in)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"errors"
+	"finance-manager-api-service/pkg/cache"
+	"finance-manager-api-service/pkg/logging"
+	"fmt"
+	"time"
+)
+
+const (
+	failuresKeyPrefix = "login_failure_count:"
+	lockKeyPrefix     = "login_lock:"
+)
+
+// Lockout locks account after maxFailures failed attempts. Every next lock within
+// failure window is twice as long as the previous one, up to maxDuration.
+type Lockout interface {
+	// Check returns how long the key is still locked, zero if it is not locked
+	Check(key string) (time.Duration, error)
+	// RecordFailure returns lock duration if this failure locked the key
+	RecordFailure(key string) (time.Duration, error)
+	Reset(key string)
+}
+
+// lock is written only by the failure that reached maxFailures, failures are counted separately
+// with atomic increment, so concurrent failures are never lost
+type lock struct {
+	Locks       int       `json:"locks"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+type lockout struct {
+	store         cache.Repository
+	logger        *logging.Logger
+	maxFailures   int
+	baseDuration  time.Duration
+	maxDuration   time.Duration
+	failureWindow time.Duration
+}
+
+// NewLockout keeps state in store, so the lock is shared between application replicas
+func NewLockout(store cache.Repository, logger *logging.Logger, maxFailures int,
+	baseDuration, maxDuration, failureWindow time.Duration) Lockout {
+	return &lockout{
+		store:         store,
+		logger:        logger,
+		maxFailures:   maxFailures,
+		baseDuration:  baseDuration,
+		maxDuration:   maxDuration,
+		failureWindow: failureWindow,
+	}
+}
+
+func (l *lockout) Check(key string) (time.Duration, error) {
+	lk, err := l.getLock(key)
+	if err != nil {
+		return 0, err
+	}
+	return max(time.Until(lk.LockedUntil), 0), nil
+}
+
+func (l *lockout) RecordFailure(key string) (time.Duration, error) {
+	count, err := l.store.Incr([]byte(failuresKeyPrefix+key), int(l.failureWindow.Seconds()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count login failure: %w", err)
+	}
+	// exactly one of concurrent failures gets the count equal to maxFailures
+	if count != int64(l.maxFailures) {
+		return 0, nil
+	}
+
+	lk, err := l.getLock(key)
+	if err != nil {
+		return 0, err
+	}
+	duration := l.baseDuration << lk.Locks
+	if duration > l.maxDuration || duration <= 0 {
+		duration = l.maxDuration
+	}
+	lk.Locks++
+	lk.LockedUntil = time.Now().Add(duration)
+	l.logger.Warnf("%d failed login attempts for %s, locked for %s", l.maxFailures, key, duration)
+
+	valueBytes, err := json.Marshal(lk)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal login lock: %w", err)
+	}
+	ttl := int((duration + l.failureWindow).Seconds())
+	if err = l.store.Set([]byte(lockKeyPrefix+key), valueBytes, ttl); err != nil {
+		return 0, fmt.Errorf("failed to save login lock: %w", err)
+	}
+	l.store.Del([]byte(failuresKeyPrefix + key))
+	return duration, nil
+}
+
+func (l *lockout) Reset(key string) {
+	l.store.Del([]byte(failuresKeyPrefix + key))
+	l.store.Del([]byte(lockKeyPrefix + key))
+}
+
+func (l *lockout) getLock(key string) (lock, error) {
+	var lk lock
+
+	valueBytes, err := l.store.Get([]byte(lockKeyPrefix + key))
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return lk, nil
+		}
+		return lk, err
+	}
+	if err = json.Unmarshal(valueBytes, &lk); err != nil {
+		return lk, fmt.Errorf("failed to unmarshal login lock: %w", err)
+	}
+	return lk, nil
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/pkg/logging"
+	"finance-manager-api-service/pkg/utils"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// KeyFunc extracts the key requests are limited by. Requests with empty key are not limited.
+type KeyFunc func(r *http.Request) string
+
+func ByIP(r *http.Request) string {
+	return utils.ClientIP(r)
+}
+
+// Middleware rejects requests exceeding the limit with 429 Too Many Requests
+func (l *Limiter) Middleware(key KeyFunc, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		k := key(r)
+		if k == "" {
+			h(w, r)
+			return
+		}
+
+		if ok, retryAfter := l.Allow(k); !ok {
+			logging.GetLogger().Warnf("rate limit exceeded for %s %s", r.Method, r.URL.Path)
+			TooManyRequests(w, r, retryAfter)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// TooManyRequests renders 429 response with Retry-After header
+func TooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	seconds := strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+	w.Header().Set("Retry-After", seconds)
+
+	appErr := apperror.TooManyRequestsError("too many requests, try again later")
+	appErr.WithParams(apperror.ErrorParams{"retry_after": seconds})
+	apperror.Render(w, r, appErr)
+}
@@ -1,28 +1,36 @@
 package rest
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"finance-manager-api-service/pkg/breaker"
 	"finance-manager-api-service/pkg/logging"
+	"finance-manager-api-service/pkg/metric"
 	"finance-manager-api-service/pkg/utils"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"path"
-	"sync"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// BaseClient is safe for concurrent use, requests are limited only by connection pool of HTTPClient
 type BaseClient struct {
 	BaseURL    string
 	HTTPClient *http.Client
 	Logger     *logging.Logger
-	mu         sync.Mutex
+	Retry      RetryPolicy
+	// nil breaker lets all requests through
+	Breaker *breaker.Breaker
+	// nil Metrics ignores requests
+	Metrics *metric.Upstream
 }
 
 func (c *BaseClient) SendRequest(req *http.Request) (*APIResponse, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	if c.HTTPClient == nil {
 		return nil, errors.New("no http client")
 	}
@@ -30,7 +38,12 @@ func (c *BaseClient) SendRequest(req *http.Request) (*APIResponse, error) {
 	req.Header.Set("Accept", "application/json; charset=utf-8")
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 
-	response, err := c.HTTPClient.Do(req)
+	done, err := c.Breaker.Allow()
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.do(req)
+	done(isUpstreamFailure(req, response, err))
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request. error: %w", err)
 	}
@@ -47,7 +60,11 @@ func (c *BaseClient) SendRequest(req *http.Request) (*APIResponse, error) {
 		var apiErr APIError
 		err = json.NewDecoder(response.Body).Decode(&apiErr)
 		if err != nil {
-			return &apiResponse, fmt.Errorf("failed to parse apperror from response body: %w", err)
+			c.Logger.Errorf("failed to parse apperror from response body: %v", err)
+			apiErr = APIError{
+				Message:          http.StatusText(response.StatusCode),
+				DeveloperMessage: "failed to parse error response of upstream service",
+			}
 		}
 		apiResponse.Error = apiErr
 	}
@@ -55,6 +72,14 @@ func (c *BaseClient) SendRequest(req *http.Request) (*APIResponse, error) {
 	return &apiResponse, nil
 }
 
+// isUpstreamFailure tells whether the outcome of request counts against circuit breaker
+func isUpstreamFailure(req *http.Request, response *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(req.Context().Err(), context.Canceled)
+	}
+	return response.StatusCode >= http.StatusInternalServerError
+}
+
 func (c *BaseClient) BuildURL(resource string, filters []FilterOptions) (string, error) {
 	var resultURL string
 	parsedURL, err := url.ParseRequestURI(c.BaseURL)
@@ -78,3 +103,54 @@ func (c *BaseClient) Close() error {
 	c.HTTPClient = nil
 	return nil
 }
+
+// HeartbeatResource is path of heartbeat endpoint relative to base URL of services
+const HeartbeatResource = "/heartbeat"
+
+// Ping sends GET to resource of the upstream service, any 2xx response means the service is up.
+// Retries and circuit breaker are bypassed, so the result shows the current state of the service.
+func (c *BaseClient) Ping(ctx context.Context, resource string) error {
+	url, err := c.BuildURL(resource, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build url: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	response, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, response.Body)
+	utils.CloseBody(c.Logger, response.Body)
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+	return nil
+}
+
+func (c *BaseClient) observe(req *http.Request, method string, response *http.Response, err error, duration time.Duration) {
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(response.StatusCode)
+	}
+	c.Metrics.ObserveCall(method, code, isUpstreamFailure(req, response, err), duration)
+}
+
+// methodLabel names request by method and path relative to BaseURL. Only the first two segments
+// of the path are kept, like "GET /operations/one/:id", so values in the path do not become labels.
+func (c *BaseClient) methodLabel(req *http.Request) string {
+	resource := req.URL.Path
+	if baseURL, err := url.Parse(c.BaseURL); err == nil {
+		resource = strings.TrimPrefix(resource, strings.TrimSuffix(baseURL.Path, "/"))
+	}
+
+	segments := strings.Split(strings.Trim(resource, "/"), "/")
+	if len(segments) > 2 {
+		segments = append(segments[:2], ":id")
+	}
+	return req.Method + " /" + strings.Join(segments, "/")
+}
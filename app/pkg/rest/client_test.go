@@ -0,0 +1,127 @@
+package rest
+
+import (
+	"finance-manager-api-service/pkg/logging"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	concurrentCallers = 32
+	upstreamLatency   = 50 * time.Millisecond
+)
+
+// slowUpstream answers after upstreamLatency and tracks the peak number of requests in flight
+type slowUpstream struct {
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (u *slowUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := u.inFlight.Add(1)
+	defer u.inFlight.Add(-1)
+	for {
+		peak := u.peak.Load()
+		if n <= peak || u.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	time.Sleep(upstreamLatency)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte("{}"))
+}
+
+func newTestClient(baseURL string) *BaseClient {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return &BaseClient{
+		BaseURL: baseURL,
+		HTTPClient: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: NewTransport(TransportConfig{
+				MaxIdleConns:        100,
+				MaxIdleConnsPerHost: concurrentCallers,
+				IdleConnTimeout:     90 * time.Second,
+				KeepAlive:           30 * time.Second,
+				DialTimeout:         5 * time.Second,
+				TLSHandshakeTimeout: 5 * time.Second,
+			}),
+		},
+		Logger: &logging.Logger{Entry: logrus.NewEntry(logger)},
+	}
+}
+
+func getResource(tb testing.TB, c *BaseClient) {
+	req, err := http.NewRequest(http.MethodGet, c.BaseURL+"/resource", nil)
+	if err != nil {
+		tb.Error(err)
+		return
+	}
+	response, err := c.SendRequest(req)
+	if err != nil {
+		tb.Error(err)
+		return
+	}
+	_, _ = io.Copy(io.Discard, response.Body())
+	_ = response.Body().Close()
+	if !response.IsOk {
+		tb.Errorf("unexpected status %d", response.StatusCode())
+	}
+}
+
+// TestBaseClientConcurrentRequests guards against serialization of requests in BaseClient:
+// 32 callers sending 10 requests each to upstream answering in 50ms take about 0.5s when
+// requests run concurrently and 16s when they run one by one.
+func TestBaseClientConcurrentRequests(t *testing.T) {
+	upstream := &slowUpstream{}
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+	client := newTestClient(server.URL)
+
+	const requestsPerCaller = 10
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < concurrentCallers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < requestsPerCaller; j++ {
+				getResource(t, client)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	total := concurrentCallers * requestsPerCaller
+	t.Logf("%d requests in %v (%.0f req/s), peak %d requests in flight",
+		total, elapsed, float64(total)/elapsed.Seconds(), upstream.peak.Load())
+
+	if peak := upstream.peak.Load(); peak < concurrentCallers/2 {
+		t.Errorf("peak requests in flight = %d, want at least %d", peak, concurrentCallers/2)
+	}
+	if serial := time.Duration(total) * upstreamLatency; elapsed > serial/4 {
+		t.Errorf("requests took %v, serialized requests would take %v", elapsed, serial)
+	}
+}
+
+func BenchmarkBaseClientConcurrentRequests(b *testing.B) {
+	server := httptest.NewServer(&slowUpstream{})
+	defer server.Close()
+	client := newTestClient(server.URL)
+
+	b.SetParallelism(concurrentCallers)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			getResource(b, client)
+		}
+	})
+}
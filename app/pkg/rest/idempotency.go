@@ -0,0 +1,38 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+type idempotencyKeyCtx struct{}
+
+// WithIdempotencyKey attaches key to the context, clients send it in Idempotency-Key header of
+// POST and PATCH requests made with the context, so these requests can be retried
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+// SetIdempotencyKey sets Idempotency-Key header of the request from its context if there is a key
+func SetIdempotencyKey(req *http.Request) {
+	if key, _ := req.Context().Value(idempotencyKeyCtx{}).(string); key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+}
+
+// IdempotencyKey returns key sent by the client, empty when there is none. Keys are never made up
+// for the client: request without key stays not retryable, as nothing else protects it from
+// being executed twice.
+func IdempotencyKey(r *http.Request) string {
+	return r.Header.Get(IdempotencyKeyHeader)
+}
+
+// ItemIdempotencyKey returns key for the item with index i of the batch derived from the key of the
+// client request, so repeated batch creates the same items. It is empty when the client sent no key.
+func ItemIdempotencyKey(r *http.Request, i int) string {
+	if key := r.Header.Get(IdempotencyKeyHeader); key != "" {
+		return key + ":" + strconv.Itoa(i)
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"finance-manager-api-service/pkg/retry"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// RetryPolicy retries requests failed with network error or with one of Statuses.
+// Only idempotent requests are retried: POST and PATCH need Idempotency-Key header.
+type RetryPolicy struct {
+	retry.Policy
+	Statuses []int
+}
+
+// do sends request and retries it according to the retry policy of the client
+func (c *BaseClient) do(req *http.Request) (*http.Response, error) {
+	retryable := isIdempotent(req) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+	method := c.methodLabel(req)
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		response, err := c.HTTPClient.Do(req)
+		c.observe(req, method, response, err, time.Since(start))
+		if !retryable || !c.Retry.Allows(attempt) || !c.shouldRetry(req, response, err) {
+			return response, err
+		}
+
+		delay := c.Retry.Delay(attempt)
+		if response != nil {
+			retryAfter, ok := parseRetryAfter(response.Header.Get("Retry-After"))
+			if ok && retryAfter > c.Retry.MaxDelay {
+				return response, err
+			}
+			delay = max(delay, retryAfter)
+		}
+		if retry.Wait(req.Context(), delay) != nil {
+			return response, err
+		}
+		c.Metrics.ObserveRetry(method)
+
+		if response != nil {
+			c.Logger.Warnf("retry %s %s after status %d, attempt %d of %d",
+				req.Method, req.URL.Path, response.StatusCode, attempt+1, c.Retry.MaxAttempts)
+			_, _ = io.Copy(io.Discard, response.Body)
+			_ = response.Body.Close()
+		} else {
+			c.Logger.Warnf("retry %s %s after error: %v, attempt %d of %d",
+				req.Method, req.URL.Path, err, attempt+1, c.Retry.MaxAttempts)
+		}
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (c *BaseClient) shouldRetry(req *http.Request, response *http.Response, err error) bool {
+	if err != nil {
+		// the request was canceled by the caller or ran out of time
+		return req.Context().Err() == nil
+	}
+	return slices.Contains(c.Retry.Statuses, response.StatusCode)
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// parseRetryAfter reads Retry-After header given in seconds or as HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
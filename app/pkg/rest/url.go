@@ -2,15 +2,48 @@ package rest
 
 import (
 	"fmt"
+	"net/url"
+	"slices"
 	"strings"
 )
 
+const (
+	OperatorEqual          = "eq"
+	OperatorNotEqual       = "neq"
+	OperatorLowerThan      = "lt"
+	OperatorLowerThanEq    = "lte"
+	OperatorGreaterThan    = "gt"
+	OperatorGreaterThanEq  = "gte"
+	OperatorBetween        = "between"
+	OperatorSubString      = "substr"
+	OperatorIn             = "in"
+	OperatorNoOperatorUsed = ""
+)
+
 type FilterOptions struct {
 	Field    string
 	Operator string
 	Values   []string
 }
 
+var operators = []string{OperatorEqual, OperatorNotEqual, OperatorLowerThan, OperatorLowerThanEq,
+	OperatorGreaterThan, OperatorGreaterThanEq, OperatorBetween, OperatorSubString, OperatorIn}
+
+// FilterError reports filter param that can not be sent to downstream services
+type FilterError struct {
+	Field   string
+	Message string
+}
+
+func (e *FilterError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// isMultiValue reports whether operator takes comma separated list of values
+func isMultiValue(operator string) bool {
+	return operator == OperatorIn || operator == OperatorBetween
+}
+
 // GET /api/users?email=in:aboba@gmail.com,123@ok.ru,...
 func (fo *FilterOptions) ToString() string {
 	if fo.Operator == "" {
@@ -18,3 +51,54 @@ func (fo *FilterOptions) ToString() string {
 	}
 	return fmt.Sprintf("%s:%s", fo.Operator, strings.Join(fo.Values, ","))
 }
+
+// ParseFilterOptions is the reverse of ToString: it builds filter options from query params
+// like money_sum=between:10,20 or description=substr:coffee. Prefix before colon is taken for
+// operator only when it is a known operator, so description=lunch: 12:30 is a plain value.
+// Downstream services split values on commas and have no escaping, so comma is allowed only
+// between values of in and between, other values with comma are rejected with FilterError.
+func ParseFilterOptions(query url.Values) ([]FilterOptions, error) {
+	var filters []FilterOptions
+	for key, values := range query {
+		for _, value := range values {
+			operator, tail := OperatorNoOperatorUsed, value
+			if prefix, rest, ok := strings.Cut(value, ":"); ok && slices.Contains(operators, prefix) {
+				operator, tail = prefix, rest
+			}
+
+			vals := strings.Split(tail, ",")
+			if len(vals) > 1 && !isMultiValue(operator) {
+				return nil, &FilterError{Field: key, Message: "comma is allowed only in lists of in and between operators"}
+			}
+
+			filters = append(filters, FilterOptions{
+				Field:    key,
+				Operator: operator,
+				Values:   vals,
+			})
+		}
+	}
+	return filters, nil
+}
+
+const ExpandParam = "expand"
+
+// ParseExpand reads comma separated list of related resources to embed into response,
+// like expand=category, and removes it from query, so it is not taken for a filter
+func ParseExpand(query url.Values, allowed ...string) (map[string]bool, error) {
+	expand := make(map[string]bool)
+	for _, value := range query[ExpandParam] {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if !slices.Contains(allowed, name) {
+				return nil, fmt.Errorf("expand supports only: %s", strings.Join(allowed, ", "))
+			}
+			expand[name] = true
+		}
+	}
+	query.Del(ExpandParam)
+	return expand, nil
+}
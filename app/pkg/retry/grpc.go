@@ -0,0 +1,50 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// UnaryClientInterceptor retries calls of idempotent methods failed with one of retryable codes.
+// onRetry is called before every retry, it may be nil.
+func UnaryClientInterceptor(policy Policy, retryable []codes.Code, idempotent func(method string) bool,
+	onRetry func(method string)) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !idempotent(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || !policy.Allows(attempt) || !slices.Contains(retryable, status.Code(err)) {
+				return err
+			}
+			if Wait(ctx, policy.Delay(attempt)) != nil {
+				return err
+			}
+			if onRetry != nil {
+				onRetry(method)
+			}
+		}
+	}
+}
+
+// ParseCodes converts names of gRPC codes like UNAVAILABLE to codes
+func ParseCodes(names []string) ([]codes.Code, error) {
+	result := make([]codes.Code, 0, len(names))
+	for _, name := range names {
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(name)))); err != nil {
+			return nil, fmt.Errorf("unknown gRPC code %q", name)
+		}
+		result = append(result, code)
+	}
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy describes how many times a call is tried and how long to wait between attempts.
+// Zero Policy makes single attempt.
+type Policy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Allows reports whether one more attempt can be made after attempt with the given number,
+// attempts are numbered from 1
+func (p Policy) Allows(attempt int) bool {
+	return attempt < p.MaxAttempts
+}
+
+// Delay returns pause before retry of the attempt: exponential backoff capped with MaxDelay
+// and full jitter, so clients that failed at the same time do not retry at the same time
+func (p Policy) Delay(attempt int) time.Duration {
+	backoff := p.MaxDelay
+	if attempt < 32 {
+		if d := p.BaseDelay << (attempt - 1); d > 0 && d < p.MaxDelay {
+			backoff = d
+		}
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return rand.N(backoff + 1)
+}
+
+// Wait sleeps for delay unless ctx is done first. It gives up at once when ctx expires before
+// the delay ends, as the next attempt would not have time to complete anyway.
+func Wait(ctx context.Context, delay time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,220 @@
+package statement
+
+import (
+	"encoding/csv"
+	"errors"
+	"finance-manager-api-service/pkg/money"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CSVMapping names CSV columns by their headers, matching is case-insensitive. Empty fields
+// are set to defaults, currency column is optional.
+type CSVMapping struct {
+	Delimiter   string `json:"delimiter" example:","`
+	Date        string `json:"date" example:"date"`
+	Amount      string `json:"amount" example:"amount"`
+	Description string `json:"description" example:"description"`
+	Currency    string `json:"currency" example:"currency"`
+	// layout in Go notation, common layouts are tried when it is empty
+	DateFormat       string `json:"date_format" example:"2006-01-02"`
+	DecimalSeparator string `json:"decimal_separator" example:"."`
+}
+
+var defaultDateFormats = []string{
+	time.DateOnly,
+	time.RFC3339,
+	time.DateTime,
+	"02.01.2006",
+	"02/01/2006",
+	"2006/01/02",
+}
+
+func (m CSVMapping) withDefaults() CSVMapping {
+	if m.Delimiter == "" {
+		m.Delimiter = ","
+	}
+	if m.Date == "" {
+		m.Date = "date"
+	}
+	if m.Amount == "" {
+		m.Amount = "amount"
+	}
+	if m.Description == "" {
+		m.Description = "description"
+	}
+	if m.Currency == "" {
+		m.Currency = "currency"
+	}
+	if m.DecimalSeparator == "" {
+		m.DecimalSeparator = "."
+	}
+	return m
+}
+
+type csvColumns struct {
+	date, amount, description, currency int
+}
+
+// ParseCSV reads statement with header line. Error is returned only when the file itself
+// is malformed, errors of single lines are reported in Transaction.Err.
+func ParseCSV(r io.Reader, mapping CSVMapping) ([]Transaction, error) {
+	mapping = mapping.withDefaults()
+	delimiter, size := utf8.DecodeRuneInString(mapping.Delimiter)
+	if size != len(mapping.Delimiter) {
+		return nil, fmt.Errorf("delimiter must be single character, got %q", mapping.Delimiter)
+	}
+	if mapping.DecimalSeparator != "." && mapping.DecimalSeparator != "," {
+		return nil, fmt.Errorf("decimal separator must be \".\" or \",\", got %q", mapping.DecimalSeparator)
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("file is empty")
+		}
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns, err := findColumns(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []Transaction
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("failed to read file: %w", err)
+			}
+			transactions = append(transactions, Transaction{Line: parseErr.Line, Err: parseErr.Err})
+			continue
+		}
+		if isBlank(record) {
+			continue
+		}
+		// FieldPos panics after a read error, so it is called only for records read successfully
+		line, _ := reader.FieldPos(0)
+		transactions = append(transactions, parseCSVRecord(line, record, columns, mapping))
+	}
+	return transactions, nil
+}
+
+func findColumns(header []string, mapping CSVMapping) (csvColumns, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	columns := csvColumns{currency: -1}
+	required := []struct {
+		name string
+		dst  *int
+	}{
+		{mapping.Date, &columns.date},
+		{mapping.Amount, &columns.amount},
+		{mapping.Description, &columns.description},
+	}
+	for _, column := range required {
+		i, ok := index[strings.ToLower(column.name)]
+		if !ok {
+			return columns, fmt.Errorf("column %q is not found in header", column.name)
+		}
+		*column.dst = i
+	}
+	if i, ok := index[strings.ToLower(mapping.Currency)]; ok {
+		columns.currency = i
+	}
+	return columns, nil
+}
+
+func parseCSVRecord(line int, record []string, columns csvColumns, mapping CSVMapping) Transaction {
+	tx := Transaction{Line: line}
+	field := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	tx.Description = field(columns.description)
+	tx.Currency = strings.ToUpper(field(columns.currency))
+
+	date, err := parseDate(field(columns.date), mapping.DateFormat)
+	if err != nil {
+		tx.Err = err
+		return tx
+	}
+	tx.Date = date
+
+	amount, err := parseAmount(field(columns.amount), mapping.DecimalSeparator)
+	if err != nil {
+		tx.Err = err
+		return tx
+	}
+	tx.Amount = amount
+	return tx
+}
+
+func parseDate(value, layout string) (time.Time, error) {
+	if layout != "" {
+		date, err := time.Parse(layout, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("date %q does not match format %q", value, layout)
+		}
+		return date, nil
+	}
+	for _, l := range defaultDateFormats {
+		if date, err := time.Parse(l, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format %q", value)
+}
+
+// parseAmount reads amount like "-1 234,56" dropping group separators
+func parseAmount(value, decimalSeparator string) (money.Amount, error) {
+	value = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '\u202f', '\'':
+			return -1
+		}
+		return r
+	}, value)
+	if decimalSeparator == "," {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.ReplaceAll(value, ",", ".")
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+
+	amount, err := money.Parse(value)
+	if err != nil {
+		return amount, fmt.Errorf("invalid amount %q", value)
+	}
+	return amount, nil
+}
+
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCSVMalformedLines(t *testing.T) {
+	content := "date,amount,description\n" +
+		"2024-01-02,-3.50,coffee\n" +
+		"2024-01-03,-10,broken \"quote\n" +
+		"2024-01-04,100,salary\n" +
+		"\"2024-01-05,-1,unterminated quote\n"
+
+	transactions, err := ParseCSV(strings.NewReader(content), CSVMapping{})
+	if err != nil {
+		t.Fatalf("ParseCSV() error = %v", err)
+	}
+
+	want := []struct {
+		line    int
+		invalid bool
+	}{
+		{line: 2},
+		{line: 3, invalid: true},
+		{line: 4},
+		{line: 5, invalid: true},
+	}
+	if len(transactions) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(transactions), len(want))
+	}
+	for i, w := range want {
+		tx := transactions[i]
+		if tx.Line != w.line {
+			t.Errorf("transaction %d: line = %d, want %d", i, tx.Line, w.line)
+		}
+		if (tx.Err != nil) != w.invalid {
+			t.Errorf("transaction %d: err = %v, want invalid %v", i, tx.Err, w.invalid)
+		}
+	}
+	if transactions[2].Description != "salary" {
+		t.Errorf("description = %q, want %q", transactions[2].Description, "salary")
+	}
+}
@@ -0,0 +1,147 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseOFX reads bank transactions of OFX/QFX statement. Both SGML (OFX 1.x) and XML (OFX 2.x)
+// files are supported: tags are scanned without building the document tree.
+func ParseOFX(r io.Reader) ([]Transaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	content := string(data)
+
+	upper := asciiUpper(content)
+	if !strings.Contains(upper, "<OFX>") {
+		return nil, errors.New("file is not an OFX document")
+	}
+	currency := strings.ToUpper(ofxValue(content, upper, "CURDEF"))
+
+	var transactions []Transaction
+	for number := 1; ; number++ {
+		start := strings.Index(upper, "<STMTTRN>")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(upper[start:], "</STMTTRN>")
+		if end < 0 {
+			end = len(upper) - start
+		}
+		block, upperBlock := content[start:start+end], upper[start:start+end]
+		content, upper = content[start+end:], upper[start+end:]
+
+		transactions = append(transactions, parseOFXTransaction(number, block, upperBlock, currency))
+	}
+	return transactions, nil
+}
+
+func parseOFXTransaction(number int, block, upperBlock, currency string) Transaction {
+	tx := Transaction{
+		Line:     number,
+		Currency: currency,
+	}
+	// <CURRENCY> and <ORIGCURRENCY> aggregates hold <CURSYM> of transaction in foreign currency
+	if sym := ofxValue(block, upperBlock, "CURSYM"); sym != "" {
+		tx.Currency = strings.ToUpper(sym)
+	}
+
+	name := ofxValue(block, upperBlock, "NAME")
+	memo := ofxValue(block, upperBlock, "MEMO")
+	switch {
+	case name == "":
+		tx.Description = memo
+	case memo == "" || strings.EqualFold(memo, name):
+		tx.Description = name
+	default:
+		tx.Description = name + " " + memo
+	}
+
+	date, err := parseOFXDate(ofxValue(block, upperBlock, "DTPOSTED"))
+	if err != nil {
+		tx.Err = err
+		return tx
+	}
+	tx.Date = date
+
+	amount, err := parseAmount(ofxValue(block, upperBlock, "TRNAMT"), ".")
+	if err != nil {
+		tx.Err = err
+		return tx
+	}
+	tx.Amount = amount
+	return tx
+}
+
+// ofxValue returns text after <TAG> up to the next tag, SGML files do not close elements
+func ofxValue(content, upper, tag string) string {
+	start := strings.Index(upper, "<"+tag+">")
+	if start < 0 {
+		return ""
+	}
+	value := content[start+len(tag)+2:]
+	if end := strings.IndexByte(value, '<'); end >= 0 {
+		value = value[:end]
+	}
+	return unescapeOFX(strings.TrimSpace(value))
+}
+
+func unescapeOFX(s string) string {
+	return strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&", "&nbsp;", " ").Replace(s)
+}
+
+// parseOFXDate reads datetime like 20240131, 20240131120000.000 or 20240131120000[-5:EST]
+func parseOFXDate(value string) (time.Time, error) {
+	datetime, zone, _ := strings.Cut(value, "[")
+	if i := strings.IndexByte(datetime, '.'); i >= 0 {
+		datetime = datetime[:i]
+	}
+
+	var layout string
+	switch len(datetime) {
+	case 8:
+		layout = "20060102"
+	case 12:
+		layout = "200601021504"
+	case 14:
+		layout = "20060102150405"
+	default:
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+
+	location := time.UTC
+	if zone != "" {
+		offset, name, _ := strings.Cut(strings.TrimSuffix(zone, "]"), ":")
+		hours, err := strconv.ParseFloat(offset, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time zone in date %q", value)
+		}
+		if name == "" {
+			name = "UTC" + offset
+		}
+		location = time.FixedZone(name, int(hours*3600))
+	}
+
+	date, err := time.ParseInLocation(layout, datetime, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
+
+// asciiUpper upper-cases only ASCII letters, so offsets in the result match the original string
+func asciiUpper(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'a' <= c && c <= 'z' {
+			b[i] = c - 'a' + 'A'
+		}
+	}
+	return string(b)
+}
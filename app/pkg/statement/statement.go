@@ -0,0 +1,26 @@
+package statement
+
+import (
+	"errors"
+	"finance-manager-api-service/pkg/money"
+	"time"
+)
+
+const (
+	FormatCSV = "csv"
+	FormatOFX = "ofx"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported statement format")
+
+// Transaction is single record of bank statement. Records that can not be parsed are returned
+// with Err, so the whole statement is not rejected because of one bad line.
+type Transaction struct {
+	// line of CSV file or number of transaction in OFX file, starting from 1
+	Line        int
+	Date        time.Time
+	Amount      money.Amount
+	Description string
+	Currency    string
+	Err         error
+}
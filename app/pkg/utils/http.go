@@ -3,6 +3,8 @@ package utils
 import (
 	"finance-manager-api-service/pkg/logging"
 	"io"
+	"net"
+	"net/http"
 )
 
 func CloseBody(logger *logging.Logger, body io.ReadCloser) {
@@ -11,3 +13,12 @@ func CloseBody(logger *logging.Logger, body io.ReadCloser) {
 		logger.Fatalf("Error closing request body: %v", err)
 	}
 }
+
+// ClientIP returns host part of request's remote address
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
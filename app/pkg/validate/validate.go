@@ -0,0 +1,224 @@
+package validate
+
+import (
+	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/pkg/money"
+	"fmt"
+	"github.com/google/uuid"
+	"math"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Struct checks fields of struct v against rules in their "validate" tags and returns
+// 422 AppError with message per invalid field, fields are named by their json names.
+//
+// Rules are separated by comma and checked in order, the first failed rule is reported:
+//
+//	omitempty       skip nil pointer or zero value
+//	required        value is not zero, strings are not blank
+//	required_with=F value is required when field F of the same struct is not zero and skipped
+//	                as with omitempty otherwise, like omitempty it must be the first rule
+//	email           string is an email address
+//	uuid            string is an uuid
+//	currency        string is ISO 4217 currency code
+//	min=n, max=n    length of string in characters or bound of number
+//	gt=n            number is greater than n
+//	finite          number is not NaN or infinity
+//	oneof=a b       value is one of space separated values
+//	maxfuture=d     time is not later than duration d from now
+//	eqfield=F       value equals to field F of the same struct
+func Struct(v any) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: expected struct, got %s", value.Kind())
+	}
+
+	fields := apperror.ErrorFields{}
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		msg, err := check(value, value.Field(i), strings.Split(tag, ","))
+		if err != nil {
+			return fmt.Errorf("validate: field %s: %w", field.Name, err)
+		}
+		if msg != "" {
+			fields[jsonName(field)] = msg
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	appErr := apperror.UnprocessableEntityError("validation failed")
+	appErr.WithFields(fields)
+	return appErr
+}
+
+func check(parent, value reflect.Value, rules []string) (string, error) {
+	optional := rules[0] == "omitempty"
+	if name, param, _ := strings.Cut(rules[0], "="); name == "required_with" {
+		other := parent.FieldByName(param)
+		if !other.IsValid() {
+			return "", fmt.Errorf("unknown field %q in required_with rule", param)
+		}
+		optional = other.IsZero()
+	}
+
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			if optional {
+				return "", nil
+			}
+			return "is required", nil
+		}
+		value = value.Elem()
+	} else if optional && value.IsZero() {
+		return "", nil
+	}
+
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		msg, err := checkRule(parent, value, name, param)
+		if err != nil || msg != "" {
+			return msg, err
+		}
+	}
+	return "", nil
+}
+
+func checkRule(parent, value reflect.Value, name, param string) (string, error) {
+	switch name {
+	case "omitempty":
+		return "", nil
+	case "required", "required_with":
+		if (value.Kind() == reflect.String && strings.TrimSpace(value.String()) == "") || value.IsZero() {
+			return "is required", nil
+		}
+	case "email":
+		// display names are not allowed and domain must have top level part
+		address, err := mail.ParseAddress(value.String())
+		if err != nil || address.Address != value.String() {
+			return "must be a valid email address", nil
+		}
+		_, domain, _ := strings.Cut(address.Address, "@")
+		if !strings.Contains(strings.Trim(domain, "."), ".") {
+			return "must be a valid email address", nil
+		}
+	case "uuid":
+		if _, err := uuid.Parse(value.String()); err != nil {
+			return "must be a valid uuid", nil
+		}
+	case "currency":
+		if !money.IsCurrencyCode(value.String()) {
+			return "must be ISO 4217 currency code", nil
+		}
+	case "min", "max", "gt":
+		bound, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s rule parameter %q", name, param)
+		}
+		return checkBound(value, name, bound)
+	case "finite":
+		if f, ok := toFloat(value); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			return "must be a finite number", nil
+		}
+	case "maxfuture":
+		d, err := time.ParseDuration(param)
+		if err != nil {
+			return "", fmt.Errorf("invalid maxfuture rule parameter %q", param)
+		}
+		t, ok := value.Interface().(time.Time)
+		if !ok {
+			return "", fmt.Errorf("maxfuture rule is not applicable to %s", value.Type())
+		}
+		if t.After(time.Now().Add(d)) {
+			return "must not be later than " + param + " from now", nil
+		}
+	case "oneof":
+		allowed := strings.Fields(param)
+		actual := fmt.Sprint(value.Interface())
+		for _, a := range allowed {
+			if a == actual {
+				return "", nil
+			}
+		}
+		return "must be one of: " + strings.Join(allowed, ", "), nil
+	case "eqfield":
+		other := parent.FieldByName(param)
+		if !other.IsValid() {
+			return "", fmt.Errorf("unknown field %q in eqfield rule", param)
+		}
+		// nil pointer counterpart matches nothing
+		if other.Kind() == reflect.Pointer && !other.IsNil() {
+			other = other.Elem()
+		}
+		if other.Kind() == reflect.Pointer || !other.Equal(value) {
+			otherField, _ := parent.Type().FieldByName(param)
+			return "must match " + jsonName(otherField), nil
+		}
+	default:
+		return "", fmt.Errorf("unknown rule %q", name)
+	}
+	return "", nil
+}
+
+func checkBound(value reflect.Value, name string, bound float64) (string, error) {
+	if value.Kind() == reflect.String {
+		length := float64(utf8.RuneCountInString(value.String()))
+		switch {
+		case name == "min" && length < bound:
+			return fmt.Sprintf("must be at least %v characters long", bound), nil
+		case name == "max" && length > bound:
+			return fmt.Sprintf("must be at most %v characters long", bound), nil
+		}
+		return "", nil
+	}
+
+	f, ok := toFloat(value)
+	if !ok {
+		return "", fmt.Errorf("%s rule is not applicable to %s", name, value.Kind())
+	}
+	switch {
+	case name == "min" && f < bound:
+		return fmt.Sprintf("must be at least %v", bound), nil
+	case name == "max" && f > bound:
+		return fmt.Sprintf("must be at most %v", bound), nil
+	case name == "gt" && !(f > bound):
+		return fmt.Sprintf("must be greater than %v", bound), nil
+	}
+	return "", nil
+}
+
+func toFloat(value reflect.Value) (float64, bool) {
+	if f, ok := value.Interface().(interface{ Float64() float64 }); ok {
+		return f.Float64(), true
+	}
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	default:
+		return 0, false
+	}
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,194 @@
+package validate
+
+import (
+	"errors"
+	"finance-manager-api-service/internal/apperror"
+	"finance-manager-api-service/pkg/money"
+	"math"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestStruct(t *testing.T) {
+	type required struct {
+		Name string `json:"name" validate:"required"`
+	}
+	type length struct {
+		Name string `json:"name" validate:"min=2,max=4"`
+	}
+	type bounds struct {
+		Age int `json:"age" validate:"min=1,max=10"`
+	}
+	type amount struct {
+		Sum money.Amount `json:"sum" validate:"gt=0"`
+	}
+	type finite struct {
+		Rate float64 `json:"rate" validate:"finite"`
+	}
+	type oneof struct {
+		Type string `json:"type" validate:"oneof=income expense"`
+	}
+	type formats struct {
+		UUID     string `json:"uuid" validate:"omitempty,uuid"`
+		Email    string `json:"email" validate:"omitempty,email"`
+		Currency string `json:"currency" validate:"omitempty,currency"`
+	}
+	type eqfield struct {
+		Password string `json:"password"`
+		Repeated string `json:"repeated" validate:"eqfield=Password"`
+	}
+	type eqfieldPointer struct {
+		Password *string `json:"password"`
+		Repeated *string `json:"repeated" validate:"omitempty,eqfield=Password"`
+	}
+	type requiredWith struct {
+		Password *string `json:"password" validate:"omitempty,min=8"`
+		Repeated *string `json:"repeated" validate:"required_with=Password,eqfield=Password"`
+	}
+	type future struct {
+		Date *time.Time `json:"date" validate:"omitempty,maxfuture=24h"`
+	}
+	type pointer struct {
+		Name *string `json:"name" validate:"max=3"`
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name  string
+		value any
+		want  apperror.ErrorFields
+	}{
+		{name: "required set", value: required{Name: "a"}},
+		{name: "required empty", value: required{}, want: apperror.ErrorFields{"name": "is required"}},
+		{name: "required blank", value: required{Name: "  "}, want: apperror.ErrorFields{"name": "is required"}},
+
+		{name: "length in bounds", value: length{Name: "абв"}},
+		{name: "length too short", value: length{Name: "a"},
+			want: apperror.ErrorFields{"name": "must be at least 2 characters long"}},
+		{name: "length too long", value: length{Name: "abcde"},
+			want: apperror.ErrorFields{"name": "must be at most 4 characters long"}},
+
+		{name: "number in bounds", value: bounds{Age: 10}},
+		{name: "number below min", value: bounds{Age: 0}, want: apperror.ErrorFields{"age": "must be at least 1"}},
+		{name: "number above max", value: bounds{Age: 11}, want: apperror.ErrorFields{"age": "must be at most 10"}},
+
+		{name: "amount greater", value: amount{Sum: money.FromUnits(1)}},
+		{name: "amount zero", value: amount{}, want: apperror.ErrorFields{"sum": "must be greater than 0"}},
+		{name: "amount negative", value: amount{Sum: money.FromUnits(-1)},
+			want: apperror.ErrorFields{"sum": "must be greater than 0"}},
+
+		{name: "finite number", value: finite{Rate: 1.5}},
+		{name: "not a number", value: finite{Rate: math.NaN()}, want: apperror.ErrorFields{"rate": "must be a finite number"}},
+		{name: "infinity", value: finite{Rate: math.Inf(1)}, want: apperror.ErrorFields{"rate": "must be a finite number"}},
+
+		{name: "oneof allowed", value: oneof{Type: "expense"}},
+		{name: "oneof other", value: oneof{Type: "transfer"},
+			want: apperror.ErrorFields{"type": "must be one of: income, expense"}},
+
+		{name: "formats empty", value: formats{}},
+		{name: "formats valid", value: formats{
+			UUID:     "7f1d3c9a-2b4e-4f6a-8c1d-0e9f8a7b6c5d",
+			Email:    "user@example.com",
+			Currency: "EUR",
+		}},
+		{name: "formats invalid", value: formats{UUID: "123", Email: "user@localhost", Currency: "EURO"},
+			want: apperror.ErrorFields{
+				"uuid":     "must be a valid uuid",
+				"email":    "must be a valid email address",
+				"currency": "must be ISO 4217 currency code",
+			}},
+		{name: "email with display name", value: formats{Email: "User <user@example.com>"},
+			want: apperror.ErrorFields{"email": "must be a valid email address"}},
+
+		{name: "eqfield equal", value: eqfield{Password: "secret", Repeated: "secret"}},
+		{name: "eqfield differs", value: eqfield{Password: "secret", Repeated: "other"},
+			want: apperror.ErrorFields{"repeated": "must match password"}},
+		{name: "eqfield pointers equal", value: eqfieldPointer{Password: ptr("secret"), Repeated: ptr("secret")}},
+		{name: "eqfield pointers differ", value: eqfieldPointer{Password: ptr("secret"), Repeated: ptr("other")},
+			want: apperror.ErrorFields{"repeated": "must match password"}},
+		{name: "eqfield nil counterpart", value: eqfieldPointer{Repeated: ptr("secret")},
+			want: apperror.ErrorFields{"repeated": "must match password"}},
+		{name: "eqfield both nil", value: eqfieldPointer{}},
+
+		{name: "required_with both nil", value: requiredWith{}},
+		{name: "required_with confirmed", value: requiredWith{Password: ptr("password1"), Repeated: ptr("password1")}},
+		{name: "required_with missing", value: requiredWith{Password: ptr("password1")},
+			want: apperror.ErrorFields{"repeated": "is required"}},
+		{name: "required_with differs", value: requiredWith{Password: ptr("password1"), Repeated: ptr("password2")},
+			want: apperror.ErrorFields{"repeated": "must match password"}},
+		{name: "required_with without counterpart", value: requiredWith{Repeated: ptr("password1")},
+			want: apperror.ErrorFields{"repeated": "must match password"}},
+
+		{name: "maxfuture nil", value: future{}},
+		{name: "maxfuture now", value: future{Date: ptr(now)}},
+		{name: "maxfuture within", value: future{Date: ptr(now.Add(23 * time.Hour))}},
+		{name: "maxfuture later", value: future{Date: ptr(now.Add(48 * time.Hour))},
+			want: apperror.ErrorFields{"date": "must not be later than 24h from now"}},
+
+		{name: "pointer without omitempty nil", value: pointer{}, want: apperror.ErrorFields{"name": "is required"}},
+		{name: "pointer is dereferenced", value: pointer{Name: ptr("abcd")},
+			want: apperror.ErrorFields{"name": "must be at most 3 characters long"}},
+		{name: "pointer to struct", value: &required{Name: "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Struct(tt.value)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Struct() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var appErr *apperror.AppError
+			if !errors.As(err, &appErr) {
+				t.Fatalf("Struct() error = %v, want AppError", err)
+			}
+			if appErr.HTTPStatus() != http.StatusUnprocessableEntity {
+				t.Errorf("status = %d, want 422", appErr.HTTPStatus())
+			}
+			if !reflect.DeepEqual(appErr.Fields, tt.want) {
+				t.Errorf("fields = %v, want %v", appErr.Fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructInvalidRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{name: "not a struct", value: "value"},
+		{name: "unknown rule", value: struct {
+			Name string `validate:"unknown"`
+		}{}},
+		{name: "invalid bound", value: struct {
+			Name string `validate:"max=ten"`
+		}{}},
+		{name: "unknown eqfield field", value: struct {
+			Name string `validate:"eqfield=Other"`
+		}{}},
+		{name: "unknown required_with field", value: struct {
+			Name string `validate:"required_with=Other"`
+		}{}},
+		{name: "maxfuture of string", value: struct {
+			Name string `validate:"maxfuture=1h"`
+		}{Name: "now"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Struct(tt.value)
+			var appErr *apperror.AppError
+			if err == nil || errors.As(err, &appErr) {
+				t.Errorf("Struct() error = %v, want internal error", err)
+			}
+		})
+	}
+}